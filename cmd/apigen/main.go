@@ -0,0 +1,479 @@
+// Command apigen generates the models and operations of the e2e suite's API
+// client from the local copy of the gateway's OpenAPI document.
+//
+//	apigen -spec testdata/openapi.yaml -out api_gen.go -package main_suite_test
+//
+// With -check, it instead lists the operations of the spec that no file of
+// the package calls and exits with status 1 when there is any.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ordered decodes a YAML mapping keeping the order of its keys, so the
+// generated code follows the spec.
+type ordered[T any] struct {
+	keys   []string
+	values map[string]T
+}
+
+func (o *ordered[T]) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: expected a mapping", n.Line)
+	}
+
+	o.values = map[string]T{}
+	for i := 0; i < len(n.Content); i += 2 {
+		var v T
+		if err := n.Content[i+1].Decode(&v); err != nil {
+			return err
+		}
+
+		o.keys = append(o.keys, n.Content[i].Value)
+		o.values[n.Content[i].Value] = v
+	}
+
+	return nil
+}
+
+type schema struct {
+	Ref        string           `yaml:"$ref"`
+	Type       string           `yaml:"type"`
+	Format     string           `yaml:"format"`
+	Nullable   bool             `yaml:"nullable"`
+	Properties ordered[*schema] `yaml:"properties"`
+	Items      *schema          `yaml:"items"`
+	GoName     string           `yaml:"x-go-name"`
+	GoType     string           `yaml:"x-go-type"`
+	OmitEmpty  bool             `yaml:"x-omitempty"`
+}
+
+type mediaType struct {
+	Schema *schema `yaml:"schema"`
+}
+
+type operation struct {
+	OperationID string `yaml:"operationId"`
+	RequestBody *struct {
+		Content ordered[mediaType] `yaml:"content"`
+	} `yaml:"requestBody"`
+	Responses ordered[struct {
+		Content ordered[mediaType] `yaml:"content"`
+	}] `yaml:"responses"`
+}
+
+type spec struct {
+	Paths      ordered[map[string]yaml.Node] `yaml:"paths"`
+	Components struct {
+		Schemas ordered[*schema] `yaml:"schemas"`
+	} `yaml:"components"`
+}
+
+var methods = []string{"get", "post", "put", "patch", "delete"}
+
+// endpoint is an operation of the spec with the path and method it serves.
+type endpoint struct {
+	method string
+	path   string
+	op     *operation
+}
+
+func (e endpoint) funcName() string {
+	return "op" + upperFirst(e.op.OperationID)
+}
+
+func loadSpec(path string) (*spec, []endpoint, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var s spec
+	if err = yaml.Unmarshal(b, &s); err != nil {
+		return nil, nil, fmt.Errorf("could not decode %s: %w", path, err)
+	}
+
+	var endpoints []endpoint
+	for _, p := range s.Paths.keys {
+		item := s.Paths.values[p]
+		for _, m := range methods {
+			n, ok := item[m]
+			if !ok {
+				continue
+			}
+
+			var op operation
+			if err = n.Decode(&op); err != nil {
+				return nil, nil, fmt.Errorf("%s %s: %w", strings.ToUpper(m), p, err)
+			}
+
+			if op.OperationID == "" {
+				return nil, nil, fmt.Errorf("%s %s: missing operationId", strings.ToUpper(m), p)
+			}
+
+			endpoints = append(endpoints, endpoint{strings.ToUpper(m), p, &op})
+		}
+	}
+
+	return &s, endpoints, nil
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// goName turns a JSON name into an exported Go identifier, with the Go
+// spelling of ID and URL, e.g. first_name → FirstName, courseUrl → CourseURL.
+func goName(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var b strings.Builder
+	for _, w := range words {
+		w = upperFirst(w)
+		for suffix, initialism := range map[string]string{"Id": "ID", "Ids": "IDs", "Url": "URL"} {
+			if strings.HasSuffix(w, suffix) {
+				w = strings.TrimSuffix(w, suffix) + initialism
+				break
+			}
+		}
+		b.WriteString(w)
+	}
+
+	return b.String()
+}
+
+// paramName turns a path parameter into an unexported Go identifier.
+func paramName(name string) string {
+	n := goName(name)
+	if n == "ID" {
+		return "id"
+	}
+
+	return strings.ToLower(n[:1]) + n[1:]
+}
+
+type generator struct {
+	spec *spec
+	buf  bytes.Buffer
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) component(ref string) (string, *schema, error) {
+	name := strings.TrimPrefix(ref, "#/components/schemas/")
+	s, ok := g.spec.Components.Schemas.values[name]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown schema %s", ref)
+	}
+
+	return name, s, nil
+}
+
+// typeName returns the Go type of a named component.
+func (g *generator) typeName(ref string) (string, error) {
+	name, s, err := g.component(ref)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case s.GoType != "":
+		return s.GoType, nil
+	case s.GoName != "":
+		return s.GoName, nil
+	}
+
+	return strings.ToLower(name[:1]) + name[1:], nil
+}
+
+// goType returns the Go type of a field: arrays of components hold pointers
+// unless x-go-type says otherwise, and nullable scalars are pointers.
+func (g *generator) goType(s *schema) (string, error) {
+	if s.GoType != "" {
+		return s.GoType, nil
+	}
+
+	if s.Ref != "" {
+		return g.typeName(s.Ref)
+	}
+
+	var t string
+	switch s.Type {
+	case "string":
+		t = "string"
+	case "boolean":
+		t = "bool"
+	case "number":
+		t = "float64"
+	case "integer":
+		t = "int"
+		if s.Format == "int32" || s.Format == "int64" {
+			t += strings.TrimPrefix(s.Format, "int")
+		}
+	case "array":
+		if s.Items == nil {
+			return "[]any", nil
+		}
+
+		item, err := g.goType(s.Items)
+		if err != nil {
+			return "", err
+		}
+
+		if s.Items.Ref != "" && s.Items.GoType == "" {
+			item = "*" + item
+		}
+
+		return "[]" + item, nil
+	case "", "object":
+		if len(s.Properties.keys) > 0 {
+			return "", fmt.Errorf("inline object schemas need a component with x-go-name")
+		}
+
+		return "any", nil
+	default:
+		return "", fmt.Errorf("unsupported type %s", s.Type)
+	}
+
+	if s.Nullable {
+		t = "*" + t
+	}
+
+	return t, nil
+}
+
+func (g *generator) model(name string, s *schema) error {
+	if s.GoType != "" {
+		return nil
+	}
+
+	typeName, err := g.typeName("#/components/schemas/" + name)
+	if err != nil {
+		return err
+	}
+
+	g.printf("type %s struct {\n", typeName)
+	for _, prop := range s.Properties.keys {
+		p := s.Properties.values[prop]
+
+		field := p.GoName
+		if field == "" {
+			field = goName(prop)
+		}
+
+		t, err := g.goType(p)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", name, prop, err)
+		}
+
+		tag := prop
+		if p.OmitEmpty {
+			tag += ",omitempty"
+		}
+
+		g.printf("\t%s %s `json:%q`\n", field, t, tag)
+	}
+	g.printf("}\n\n")
+
+	return nil
+}
+
+// content returns the first media type of content with a schema.
+func content(c ordered[mediaType]) (string, *schema) {
+	for _, k := range c.keys {
+		if s := c.values[k].Schema; s != nil {
+			return k, s
+		}
+	}
+
+	return "", nil
+}
+
+func (g *generator) operation(e endpoint) error {
+	var params, args []string
+	path := `"` + e.path + `"`
+	for _, segment := range strings.Split(e.path, "/") {
+		if !strings.HasPrefix(segment, "{") {
+			continue
+		}
+
+		p := paramName(strings.Trim(segment, "{}"))
+		params = append(params, p+" string")
+		path = strings.Replace(path, segment, `"+`+p+`+"`, 1)
+	}
+	path = strings.TrimSuffix(path, `+""`)
+
+	body, contentType := "nil", ""
+	if e.op.RequestBody != nil {
+		ct, s := content(e.op.RequestBody.Content)
+		if s == nil {
+			return fmt.Errorf("%s %s: request body without schema", e.method, e.path)
+		}
+
+		t, err := g.goType(s)
+		if err != nil {
+			return fmt.Errorf("%s %s: %w", e.method, e.path, err)
+		}
+
+		params = append(params, "req "+t)
+		body = "req"
+		if ct != "application/ld+json" {
+			contentType = ct
+		}
+	}
+	params = append(params, "credentials userCredentials", "opts ...requestOpt")
+
+	var respType string
+	for _, status := range e.op.Responses.keys {
+		if !strings.HasPrefix(status, "2") {
+			continue
+		}
+
+		if _, s := content(e.op.Responses.values[status].Content); s != nil {
+			t, err := g.goType(s)
+			if err != nil {
+				return fmt.Errorf("%s %s: %w", e.method, e.path, err)
+			}
+			respType = t
+		}
+		break
+	}
+
+	method := "http.Method" + upperFirst(strings.ToLower(e.method))
+	g.printf("// %s sends %s %s.\n", e.funcName(), e.method, e.path)
+	if respType == "" {
+		g.printf("func (cli *apiClient) %s(%s) error {\n", e.funcName(), strings.Join(params, ", "))
+	} else {
+		g.printf("func (cli *apiClient) %s(%s) (*%s, error) {\n", e.funcName(), strings.Join(params, ", "), respType)
+	}
+
+	if contentType != "" {
+		g.printf("opts = append([]requestOpt{withContentType(%q)}, opts...)\n\n", contentType)
+	}
+
+	if respType == "" {
+		g.printf("return cli.sendRequest(%s, %s, %s, credentials, nil, opts...)\n}\n\n", method, path, body)
+		return nil
+	}
+
+	args = append(args, method, path, body, "credentials", "&resp", "opts...")
+	g.printf("var resp %s\n", respType)
+	g.printf("if err := cli.sendRequest(%s); err != nil {\nreturn nil, err\n}\n\n", strings.Join(args, ", "))
+	g.printf("return &resp, nil\n}\n\n")
+
+	return nil
+}
+
+func generate(specPath, pkg string) ([]byte, error) {
+	s, endpoints, err := loadSpec(specPath)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{spec: s}
+	g.printf("// Code generated by apigen from %s. DO NOT EDIT.\n\n", filepath.ToSlash(specPath))
+	g.printf("package %s\n\nimport \"net/http\"\n\n", pkg)
+
+	for _, name := range s.Components.Schemas.keys {
+		if err = g.model(name, s.Components.Schemas.values[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, e := range endpoints {
+		if err = g.operation(e); err != nil {
+			return nil, err
+		}
+	}
+
+	return format.Source(g.buf.Bytes())
+}
+
+// uncalled returns the endpoints whose operation is not referenced by the
+// files of dir, the generated one aside.
+func uncalled(specPath, dir, out string) ([]endpoint, error) {
+	_, endpoints, err := loadSpec(specPath)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return fi.Name() != filepath.Base(out)
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	called := map[string]bool{}
+	for _, pkg := range pkgs {
+		for _, f := range pkg.Files {
+			ast.Inspect(f, func(n ast.Node) bool {
+				if sel, ok := n.(*ast.SelectorExpr); ok {
+					called[sel.Sel.Name] = true
+				}
+				return true
+			})
+		}
+	}
+
+	return slices.DeleteFunc(endpoints, func(e endpoint) bool {
+		return called[e.funcName()]
+	}), nil
+}
+
+func main() {
+	specPath := flag.String("spec", "testdata/openapi.yaml", "OpenAPI document to generate from")
+	out := flag.String("out", "api_gen.go", "generated file")
+	pkg := flag.String("package", "main_suite_test", "package of the generated file")
+	check := flag.Bool("check", false, "list the operations the package never calls instead of generating")
+	flag.Parse()
+
+	if *check {
+		endpoints, err := uncalled(*specPath, filepath.Dir(*out), *out)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for _, e := range endpoints {
+			fmt.Printf("%s %s (%s) is never called\n", e.method, e.path, e.funcName())
+		}
+
+		if len(endpoints) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	b, err := generate(*specPath, *pkg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err = os.WriteFile(*out, b, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,518 @@
+// Code generated by apigen from testdata/openapi.yaml. DO NOT EDIT.
+
+package main_suite_test
+
+import "net/http"
+
+type createOrganizationRequest struct {
+	Slug   string `json:"slug"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+type createOrganizationResponse struct {
+	Organization organization `json:"organization"`
+}
+
+type organization struct {
+	ID          string `json:"id"`
+	Slug        string `json:"slug"`
+	Name        string `json:"name"`
+	IDPType     string `json:"idpType"`
+	IDPGroupID  string `json:"idpGroupId"`
+	IDPClientID string `json:"idpClientId"`
+	Status      string `json:"status"`
+}
+
+type createUserRequest struct {
+	Email     string   `json:"email"`
+	FirstName string   `json:"first_name"`
+	LastName  string   `json:"last_name"`
+	Roles     []string `json:"roles"`
+}
+
+type user struct {
+	ID        string   `json:"id"`
+	Email     string   `json:"email"`
+	FirstName string   `json:"first_name"`
+	LastName  string   `json:"last_name"`
+	Roles     []string `json:"roles"`
+}
+
+type attributeOption struct {
+	Label         string `json:"label"`
+	SequenceOrder int    `json:"sequenceOrder"`
+}
+
+type createOrgAttributeRequest struct {
+	AttributeOptions []*attributeOption `json:"attributeOptions"`
+	Name             string             `json:"name"`
+	Organization     iri                `json:"organization"`
+	Type             string             `json:"type"`
+}
+
+type orgAttribute struct {
+	ID               string             `json:"id"`
+	Name             string             `json:"name"`
+	Type             string             `json:"types"`
+	Status           string             `json:"status"`
+	AttributeOptions []*attributeOption `json:"attributeOptions"`
+}
+
+type orgAttributesResponse struct {
+	Attributes []*orgAttribute `json:"attributes"`
+}
+
+type assignUserAttributesRequest struct {
+	UserID      string `json:"userId"`
+	AttributeID string `json:"attributeId"`
+	Value       string `json:"value"`
+}
+
+type attributeFilter struct {
+	AttributeID    string `json:"attributeId"`
+	FilterOperator string `json:"filterOperator"`
+	Value          string `json:"value"`
+}
+
+type createLearningGroupRequest struct {
+	Name       string             `json:"name"`
+	Attributes []*attributeFilter `json:"attributes"`
+}
+
+type learningGroup struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	UserCount int    `json:"userCount"`
+}
+
+type createCourseRequest struct {
+	OrganizationId string `json:"organizationId"`
+	Title          string `json:"title"`
+	VersionName    string `json:"versionName"`
+}
+
+type updateCourseRequest struct {
+	Title string `json:"title,omitempty"`
+	State string `json:"state,omitempty"`
+}
+
+type course struct {
+	IRI iri    `json:"@id,omitempty"`
+	ID  string `json:"id"`
+}
+
+type createLearningItemRequest struct {
+	Course        iri    `json:"course"`
+	Description   string `json:"description"`
+	Name          string `json:"name"`
+	Points        int    `json:"points"`
+	SequenceOrder int    `json:"sequenceOrder"`
+	State         string `json:"state"`
+	Type          string `json:"type"`
+}
+
+type learningItem struct {
+	IRI                   iri    `json:"@id,omitempty"`
+	ID                    string `json:"id"`
+	LearningItemVersionID string `json:"learningItemVersionId"`
+}
+
+type cardContentBlock struct {
+	ID      string  `json:"id"`
+	Type    string  `json:"type"`
+	JSON    any     `json:"json,omitempty"`
+	MediaID *string `json:"mediaId,omitempty"`
+	Name    *string `json:"name,omitempty"`
+}
+
+type cardJSON struct {
+	Version       string             `json:"version"`
+	Description   string             `json:"description"`
+	TemplateType  *string            `json:"templateType"`
+	ContentBlocks []cardContentBlock `json:"contentBlocks"`
+}
+
+type createCardRequest struct {
+	LearningItem    iri    `json:"learningItem,omitempty"`
+	Type            string `json:"type"`
+	Title           string `json:"title"`
+	SequenceOrder   int    `json:"sequenceOrder"`
+	ConfidenceCheck bool   `json:"confidenceCheck"`
+	JSON            any    `json:"json"`
+}
+
+type createCardsRequest struct {
+	Cards []*createCardRequest `json:"cards"`
+}
+
+type card struct {
+	IRI  iri      `json:"@id,omitempty"`
+	ID   string   `json:"id"`
+	JSON cardJSON `json:"json"`
+}
+
+type cardsResponse struct {
+	Cards []*card `json:"cards"`
+}
+
+type createLearningPlanRequest struct {
+	Name        string `json:"name"`
+	ActivatedAt string `json:"activatedAt"`
+}
+
+type updateLearningPlanRequest struct {
+	State int `json:"state,omitempty"`
+}
+
+type addCoursesToLearningPlanRequest struct {
+	Courses []string `json:"courses"`
+}
+
+type addGroupsToLearningPlanRequest struct {
+	LearningGroupIDs []string `json:"learningGroupIds"`
+}
+
+type learningPlan struct {
+	IRI            iri             `json:"@id,omitempty"`
+	ID             string          `json:"id"`
+	Courses        []course        `json:"courses"`
+	LearningGroups []learningGroup `json:"learningGroups"`
+}
+
+type invitation struct {
+	IRI               iri    `json:"@id,omitempty"`
+	ID                string `json:"id"`
+	InvitedUserID     string `json:"invitedUserId"`
+	DownloadedOffline bool   `json:"downloadedOffline"`
+	Status            string `json:"status"`
+	ExpiresAt         string `json:"expiresAt"`
+}
+
+type courseBundleRequest struct {
+	OrgID          string `json:"orgId"`
+	CourseID       string `json:"courseId"`
+	LearningPlanID string `json:"learningplanId"`
+	DeviceID       string `json:"deviceId"`
+	OfflineMode    string `json:"offlineMode"`
+}
+
+type courseBundleResponse struct {
+	JobID string `json:"jobId"`
+}
+
+type courseBundleURLResponse struct {
+	CourseURL    string `json:"courseUrl"`
+	BundleStatus string `json:"bundleStatus"`
+}
+
+type invitationEnrollRequest struct {
+	InvitationID string `json:"invitationId"`
+}
+
+type invitationEnrollResponse struct {
+	ID           string              `json:"id"`
+	InvitationID string              `json:"invitationId"`
+	Status       enrollmentJobStatus `json:"status"`
+	Message      string              `json:"message"`
+}
+
+type cloneEnrollmentRequest struct {
+	InvitationID string `json:"invitationId"`
+}
+
+type cardEnrollment struct {
+	CardEnrollmentId         string   `json:"cardEnrollmentId,omitempty"`
+	LearningItemEnrollmentId string   `json:"learningItemEnrollmentId,omitempty"`
+	CardId                   string   `json:"cardId,omitempty"`
+	Score                    int32    `json:"score,omitempty"`
+	ElapsedSec               int32    `json:"elapsedSec,omitempty"`
+	ServerEnrollment         bool     `json:"serverEnrollment,omitempty"`
+	DeviceID                 string   `json:"deviceId,omitempty"`
+	Approved                 bool     `json:"approved,omitempty"`
+	Answer                   []string `json:"answer,omitempty"`
+	Confidence               int32    `json:"confidence,omitempty"`
+	CreatedAt                string   `json:"createdAt,omitempty"`
+	UpdatedAt                string   `json:"updatedAt,omitempty"`
+	StartedAt                string   `json:"startedAt,omitempty"`
+	CompletedAt              string   `json:"completedAt,omitempty"`
+	Progress                 int32    `json:"progress,omitempty"`
+	TotalPoints              int32    `json:"totalPoints,omitempty"`
+}
+
+type learningItemEnrollment struct {
+	LearningItemEnrollmentId string            `json:"learningItemEnrollmentId,omitempty"`
+	CourseEnrollmentId       string            `json:"courseEnrollmentId,omitempty"`
+	LearningItemId           string            `json:"learningItemId,omitempty"`
+	DeviceId                 string            `json:"deviceId,omitempty"`
+	StartedAt                string            `json:"startedAt,omitempty"`
+	UpdatedAt                string            `json:"updatedAt,omitempty"`
+	CompletedAt              string            `json:"completedAt,omitempty"`
+	Progress                 int32             `json:"progress,omitempty"`
+	TotalPoints              int32             `json:"totalPoints,omitempty"`
+	CardEnrollments          []*cardEnrollment `json:"cardEnrollments,omitempty"`
+}
+
+type cloneEnrollmentResponse struct {
+	CourseEnrollmentID      string                    `json:"courseEnrollmentId"`
+	CourseID                string                    `json:"courseId"`
+	InvitationID            string                    `json:"invitationId"`
+	LearningItemEnrollments []*learningItemEnrollment `json:"learningItemEnrollments"`
+	UserID                  string                    `json:"userId"`
+}
+
+type syncEnrollmentRequest struct {
+	LearningItemEnrollments []*learningItemEnrollment `json:"LearningItemEnrollments"`
+}
+
+type syncEnrollmentsResult struct {
+	LearningItemEnrollmentID string `json:"learningItemEnrollmentId"`
+	Success                  bool   `json:"success"`
+	Message                  string `json:"message"`
+}
+
+type syncEnrollmentsResponse struct {
+	Results []*syncEnrollmentsResult `json:"results"`
+}
+
+type getDuplicateEnrollmentsRequest struct {
+	LearningItemEnrollmentIDs []string `json:"learningItemEnrollmentIds"`
+}
+
+type learningItemEnrollmentsResponse struct {
+	LearningItemEnrollments []*learningItemEnrollment `json:"learningItemEnrollments"`
+}
+
+// opCreateOrganization sends POST /v1/organizations.
+func (cli *apiClient) opCreateOrganization(req createOrganizationRequest, credentials userCredentials, opts ...requestOpt) (*createOrganizationResponse, error) {
+	var resp createOrganizationResponse
+	if err := cli.sendRequest(http.MethodPost, "/v1/organizations", req, credentials, &resp, opts...); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// opCreateUser sends POST /v1/users.
+func (cli *apiClient) opCreateUser(req createUserRequest, credentials userCredentials, opts ...requestOpt) (*user, error) {
+	var resp user
+	if err := cli.sendRequest(http.MethodPost, "/v1/users", req, credentials, &resp, opts...); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// opListAttributes sends GET /v1/attributes.
+func (cli *apiClient) opListAttributes(credentials userCredentials, opts ...requestOpt) (*orgAttributesResponse, error) {
+	var resp orgAttributesResponse
+	if err := cli.sendRequest(http.MethodGet, "/v1/attributes", nil, credentials, &resp, opts...); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// opCreateAttribute sends POST /v1/attributes.
+func (cli *apiClient) opCreateAttribute(req createOrgAttributeRequest, credentials userCredentials, opts ...requestOpt) (*orgAttribute, error) {
+	var resp orgAttribute
+	if err := cli.sendRequest(http.MethodPost, "/v1/attributes", req, credentials, &resp, opts...); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// opAssignUserAttribute sends POST /v1/user_attributes.
+func (cli *apiClient) opAssignUserAttribute(req assignUserAttributesRequest, credentials userCredentials, opts ...requestOpt) (*assignUserAttributesRequest, error) {
+	var resp assignUserAttributesRequest
+	if err := cli.sendRequest(http.MethodPost, "/v1/user_attributes", req, credentials, &resp, opts...); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// opCreateLearningGroup sends POST /v1/learning_groups.
+func (cli *apiClient) opCreateLearningGroup(req createLearningGroupRequest, credentials userCredentials, opts ...requestOpt) (*learningGroup, error) {
+	var resp learningGroup
+	if err := cli.sendRequest(http.MethodPost, "/v1/learning_groups", req, credentials, &resp, opts...); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// opCreateCourse sends POST /v1/courses.
+func (cli *apiClient) opCreateCourse(req createCourseRequest, credentials userCredentials, opts ...requestOpt) (*course, error) {
+	var resp course
+	if err := cli.sendRequest(http.MethodPost, "/v1/courses", req, credentials, &resp, opts...); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// opUpdateCourse sends PATCH /v1/courses/{id}.
+func (cli *apiClient) opUpdateCourse(id string, req updateCourseRequest, credentials userCredentials, opts ...requestOpt) (*course, error) {
+	opts = append([]requestOpt{withContentType("application/merge-patch+json")}, opts...)
+
+	var resp course
+	if err := cli.sendRequest(http.MethodPatch, "/v1/courses/"+id, req, credentials, &resp, opts...); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// opCreateLearningItem sends POST /v1/learning_items.
+func (cli *apiClient) opCreateLearningItem(req createLearningItemRequest, credentials userCredentials, opts ...requestOpt) (*learningItem, error) {
+	var resp learningItem
+	if err := cli.sendRequest(http.MethodPost, "/v1/learning_items", req, credentials, &resp, opts...); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// opCreateLearningItemCards sends POST /v1/learning_items/{id}/cards.
+func (cli *apiClient) opCreateLearningItemCards(id string, req createCardsRequest, credentials userCredentials, opts ...requestOpt) (*cardsResponse, error) {
+	var resp cardsResponse
+	if err := cli.sendRequest(http.MethodPost, "/v1/learning_items/"+id+"/cards", req, credentials, &resp, opts...); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// opCreateCard sends POST /v1/cards.
+func (cli *apiClient) opCreateCard(req createCardRequest, credentials userCredentials, opts ...requestOpt) (*card, error) {
+	var resp card
+	if err := cli.sendRequest(http.MethodPost, "/v1/cards", req, credentials, &resp, opts...); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// opCreateLearningPlan sends POST /v1/learning_plans.
+func (cli *apiClient) opCreateLearningPlan(req createLearningPlanRequest, credentials userCredentials, opts ...requestOpt) (*learningPlan, error) {
+	var resp learningPlan
+	if err := cli.sendRequest(http.MethodPost, "/v1/learning_plans", req, credentials, &resp, opts...); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// opUpdateLearningPlan sends PATCH /v1/learning_plans/{id}.
+func (cli *apiClient) opUpdateLearningPlan(id string, req updateLearningPlanRequest, credentials userCredentials, opts ...requestOpt) (*learningPlan, error) {
+	opts = append([]requestOpt{withContentType("application/merge-patch+json")}, opts...)
+
+	var resp learningPlan
+	if err := cli.sendRequest(http.MethodPatch, "/v1/learning_plans/"+id, req, credentials, &resp, opts...); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// opAddLearningPlanCourses sends POST /v1/learning_plans/{id}/courses.
+func (cli *apiClient) opAddLearningPlanCourses(id string, req addCoursesToLearningPlanRequest, credentials userCredentials, opts ...requestOpt) (*learningPlan, error) {
+	var resp learningPlan
+	if err := cli.sendRequest(http.MethodPost, "/v1/learning_plans/"+id+"/courses", req, credentials, &resp, opts...); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// opAddLearningPlanGroups sends POST /v1/learning_plans/{id}/learning_plan_groups.
+func (cli *apiClient) opAddLearningPlanGroups(id string, req addGroupsToLearningPlanRequest, credentials userCredentials, opts ...requestOpt) (*learningPlan, error) {
+	var resp learningPlan
+	if err := cli.sendRequest(http.MethodPost, "/v1/learning_plans/"+id+"/learning_plan_groups", req, credentials, &resp, opts...); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// opListInvitations sends GET /v1/invitations.
+func (cli *apiClient) opListInvitations(credentials userCredentials, opts ...requestOpt) (*hydraCollection[*invitation], error) {
+	var resp hydraCollection[*invitation]
+	if err := cli.sendRequest(http.MethodGet, "/v1/invitations", nil, credentials, &resp, opts...); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// opCreateCourseBundle sends POST /v1/course-bundle.
+func (cli *apiClient) opCreateCourseBundle(req courseBundleRequest, credentials userCredentials, opts ...requestOpt) (*courseBundleResponse, error) {
+	var resp courseBundleResponse
+	if err := cli.sendRequest(http.MethodPost, "/v1/course-bundle", req, credentials, &resp, opts...); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// opGetCourseBundleUrl sends GET /v1/course-bundle-url/{jobId}.
+func (cli *apiClient) opGetCourseBundleUrl(jobID string, credentials userCredentials, opts ...requestOpt) (*courseBundleURLResponse, error) {
+	var resp courseBundleURLResponse
+	if err := cli.sendRequest(http.MethodGet, "/v1/course-bundle-url/"+jobID, nil, credentials, &resp, opts...); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// opEnrollInvitation sends POST /v1/invitation-enroll.
+func (cli *apiClient) opEnrollInvitation(req invitationEnrollRequest, credentials userCredentials, opts ...requestOpt) (*invitationEnrollResponse, error) {
+	var resp invitationEnrollResponse
+	if err := cli.sendRequest(http.MethodPost, "/v1/invitation-enroll", req, credentials, &resp, opts...); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// opGetEnrollmentJob sends GET /v1/invitation-enroll/{id}.
+func (cli *apiClient) opGetEnrollmentJob(id string, credentials userCredentials, opts ...requestOpt) (*invitationEnrollResponse, error) {
+	var resp invitationEnrollResponse
+	if err := cli.sendRequest(http.MethodGet, "/v1/invitation-enroll/"+id, nil, credentials, &resp, opts...); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// opCloneEnrollment sends POST /v1/enrollments/clone.
+func (cli *apiClient) opCloneEnrollment(req cloneEnrollmentRequest, credentials userCredentials, opts ...requestOpt) (*cloneEnrollmentResponse, error) {
+	var resp cloneEnrollmentResponse
+	if err := cli.sendRequest(http.MethodPost, "/v1/enrollments/clone", req, credentials, &resp, opts...); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// opSyncEnrollments sends POST /v1/enrollments/sync.
+func (cli *apiClient) opSyncEnrollments(req syncEnrollmentRequest, credentials userCredentials, opts ...requestOpt) (*syncEnrollmentsResponse, error) {
+	var resp syncEnrollmentsResponse
+	if err := cli.sendRequest(http.MethodPost, "/v1/enrollments/sync", req, credentials, &resp, opts...); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// opGetDuplicateEnrollments sends POST /v1/enrollments/duplicate.
+func (cli *apiClient) opGetDuplicateEnrollments(req getDuplicateEnrollmentsRequest, credentials userCredentials, opts ...requestOpt) (*learningItemEnrollmentsResponse, error) {
+	var resp learningItemEnrollmentsResponse
+	if err := cli.sendRequest(http.MethodPost, "/v1/enrollments/duplicate", req, credentials, &resp, opts...); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
@@ -0,0 +1,237 @@
+package main_suite_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	bundleManifestFile = "manifest.json"
+	bundleCourseFile   = "course.json"
+	bundleInvitesFile  = "invitations.json"
+	bundleMediaDir     = "media/"
+)
+
+type bundleFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+type bundleManifest struct {
+	Version        string       `json:"version"`
+	OrgID          string       `json:"orgId"`
+	CourseID       string       `json:"courseId"`
+	LearningPlanID string       `json:"learningplanId"`
+	DeviceID       string       `json:"deviceId"`
+	OfflineMode    string       `json:"offlineMode"`
+	UserID         string       `json:"userId,omitempty"`
+	CreatedAt      string       `json:"createdAt"`
+	ExpiresAt      string       `json:"expiresAt"`
+	Files          []bundleFile `json:"files"`
+}
+
+type bundleCard struct {
+	ID            string   `json:"id"`
+	Type          string   `json:"type"`
+	Title         string   `json:"title"`
+	SequenceOrder int      `json:"sequenceOrder"`
+	JSON          cardJSON `json:"json"`
+}
+
+type bundleLearningItem struct {
+	ID            string        `json:"id"`
+	Type          string        `json:"type"`
+	Name          string        `json:"name"`
+	SequenceOrder int           `json:"sequenceOrder"`
+	Points        int           `json:"points"`
+	Cards         []*bundleCard `json:"cards"`
+}
+
+type bundleCourse struct {
+	ID            string                `json:"id"`
+	OrgID         string                `json:"orgId"`
+	Title         string                `json:"title"`
+	LearningItems []*bundleLearningItem `json:"learningItems"`
+}
+
+// courseBundle is an unpacked offline course bundle. Shared device bundles
+// carry the invitations of every learner allowed on the device, personal
+// ones only the invitation of the learner who downloaded it.
+type courseBundle struct {
+	manifest    bundleManifest
+	course      bundleCourse
+	invitations []*invitation
+	files       map[string][]byte
+}
+
+func (b *courseBundle) invitedUserIDs() []string {
+	ids := make([]string, len(b.invitations))
+	for i, inv := range b.invitations {
+		ids[i] = inv.InvitedUserID
+	}
+
+	return ids
+}
+
+func (b *courseBundle) media() map[string][]byte {
+	media := map[string][]byte{}
+	for name, content := range b.files {
+		if mediaID, ok := strings.CutPrefix(name, bundleMediaDir); ok && mediaID != "" {
+			media[mediaID] = content
+		}
+	}
+
+	return media
+}
+
+func unpackBundle(b []byte) (*courseBundle, error) {
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &courseBundle{files: map[string][]byte{}}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		bundle.files[f.Name] = content
+	}
+
+	for name, v := range map[string]any{
+		bundleManifestFile: &bundle.manifest,
+		bundleCourseFile:   &bundle.course,
+		bundleInvitesFile:  &bundle.invitations,
+	} {
+		content, ok := bundle.files[name]
+		if !ok {
+			return nil, fmt.Errorf("bundle has no %s", name)
+		}
+
+		if err = json.Unmarshal(content, v); err != nil {
+			return nil, fmt.Errorf("could not decode %s: %w", name, err)
+		}
+	}
+
+	return bundle, nil
+}
+
+func checksum(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+var (
+	localBundleServer     *httptest.Server
+	localBundleServerOnce sync.Once
+)
+
+// bundleLocation resolves where a bundle should be fetched from. The course
+// URL is used as is unless a stand-in is configured: BUNDLE_DIR serves the
+// bundles from a local directory, BUNDLE_BASE_URL points to a MinIO or plain
+// file server holding the same object keys.
+func bundleLocation(courseURL string) (string, error) {
+	u, err := url.Parse(courseURL)
+	if err != nil {
+		return "", err
+	}
+
+	base := config.bundleBaseURL
+	if config.bundleDir != "" {
+		localBundleServerOnce.Do(func() {
+			localBundleServer = httptest.NewServer(http.FileServer(http.Dir(config.bundleDir)))
+		})
+		base = localBundleServer.URL
+	}
+
+	if base == "" {
+		return courseURL, nil
+	}
+
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+
+	b.Path = path.Join(b.Path, u.Path)
+	b.RawQuery = u.RawQuery
+	return b.String(), nil
+}
+
+// bundleURLExpiry returns when a presigned course URL stops being valid, read
+// from its X-Amz-Date and X-Amz-Expires parameters. ok is false when the URL
+// is not presigned.
+func bundleURLExpiry(courseURL string) (expiresAt time.Time, ok bool) {
+	u, err := url.Parse(courseURL)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	q := u.Query()
+	signedAt, err := time.Parse("20060102T150405Z", q.Get("X-Amz-Date"))
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	seconds, err := strconv.Atoi(q.Get("X-Amz-Expires"))
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return signedAt.Add(time.Duration(seconds) * time.Second), true
+}
+
+func downloadBundle(courseURL string) (*courseBundle, error) {
+	location, err := bundleLocation(courseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newHttpError(string(b), resp.StatusCode)
+	}
+
+	return unpackBundle(b)
+}
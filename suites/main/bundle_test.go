@@ -0,0 +1,128 @@
+package main_suite_test
+
+import (
+	"bytes"
+	"time"
+)
+
+// waitForBundle polls the bundle job until it completes, fails or is
+// invalidated, timing it from the first poll.
+func (s *courseSuite) waitForBundle(jobID string, credentials userCredentials) *courseBundleURLResponse {
+	var resp *courseBundleURLResponse
+	var err error
+	start := time.Now()
+	for i := 0; i < 10; i += 1 {
+		resp, err = s.apiClient.courseBundleURL(jobID, credentials)
+		s.Require().Nil(err)
+
+		switch resp.BundleStatus {
+		case bundleStatusCompleted, bundleStatusFailed, bundleStatusInvalidated:
+			s.recordJob("bundle", jobID, start)
+			return resp
+		}
+
+		time.Sleep(time.Second * 5)
+	}
+
+	return resp
+}
+
+// bundleCourse requests a bundle and downloads it once the upload completed.
+func (s *courseSuite) bundleCourse(req courseBundleRequest, credentials userCredentials) (string, *courseBundle) {
+	courseBundleResp, err := s.apiClient.courseBundle(req, credentials)
+	s.Require().Nil(err)
+	s.Require().NotEmpty(courseBundleResp.JobID)
+
+	resp := s.waitForBundle(courseBundleResp.JobID, credentials)
+	s.Require().Equal(bundleStatusCompleted, resp.BundleStatus)
+
+	return courseBundleResp.JobID, s.downloadBundle(resp.CourseURL)
+}
+
+func (s *courseSuite) downloadBundle(courseURL string) *courseBundle {
+	s.Require().NotEmpty(courseURL)
+
+	bundle, err := downloadBundle(courseURL)
+	s.Require().Nil(err)
+
+	return bundle
+}
+
+// verifyBundle checks an unpacked bundle against the request that produced it
+// and the course of courseWorld.
+func (s *courseSuite) verifyBundle(bundle *courseBundle, req courseBundleRequest) {
+	manifest := bundle.manifest
+	s.Assert().Equal(req.OrgID, manifest.OrgID)
+	s.Assert().Equal(req.CourseID, manifest.CourseID)
+	s.Assert().Equal(req.LearningPlanID, manifest.LearningPlanID)
+	s.Assert().Equal(req.DeviceID, manifest.DeviceID)
+	s.Assert().Equal(req.OfflineMode, manifest.OfflineMode)
+
+	// Every file but the manifest itself must be listed with a valid checksum.
+	listed := map[string]bool{bundleManifestFile: true}
+	for _, f := range manifest.Files {
+		listed[f.Path] = true
+
+		content, ok := bundle.files[f.Path]
+		if !s.Assert().True(ok, "missing bundle file %s", f.Path) {
+			continue
+		}
+
+		s.Assert().Equal(f.Size, int64(len(content)), "size of %s", f.Path)
+		s.Assert().Equal(f.SHA256, checksum(content), "checksum of %s", f.Path)
+	}
+
+	for name := range bundle.files {
+		s.Assert().True(listed[name], "bundle file %s is not in the manifest", name)
+	}
+
+	s.Assert().Equal(s.course.ID, bundle.course.ID)
+	s.Assert().Equal(s.org.ID, bundle.course.OrgID)
+
+	expected := map[string][]*card{
+		s.lesson.ID: s.lessonCards,
+		s.quiz.ID:   s.quizCards,
+	}
+	s.Require().Equal(len(expected), len(bundle.course.LearningItems))
+
+	media := bundle.media()
+	for _, li := range bundle.course.LearningItems {
+		cards, ok := expected[li.ID]
+		if !s.Assert().True(ok, "unexpected learning item %s", li.ID) {
+			continue
+		}
+
+		if !s.Assert().Equal(len(cards), len(li.Cards), "cards of learning item %s", li.ID) {
+			continue
+		}
+
+		authored := map[string]*card{}
+		for _, c := range cards {
+			authored[c.ID] = c
+		}
+
+		for _, c := range li.Cards {
+			a, ok := authored[c.ID]
+			if !s.Assert().True(ok, "unexpected card %s in learning item %s", c.ID, li.ID) {
+				continue
+			}
+
+			s.Require().Equal(len(a.JSON.ContentBlocks), len(c.JSON.ContentBlocks), "content blocks of card %s", c.ID)
+			for i, block := range c.JSON.ContentBlocks {
+				s.Assert().Equal(a.JSON.ContentBlocks[i].Type, block.Type, "content block %s of card %s", block.ID, c.ID)
+
+				if block.MediaID == nil || *block.MediaID == "" {
+					continue
+				}
+
+				_, ok := media[*block.MediaID]
+				s.Assert().True(ok, "media %s of card %s is not bundled", *block.MediaID, c.ID)
+			}
+		}
+	}
+
+	// Nothing from another tenant may leak into the bundle.
+	for name, content := range bundle.files {
+		s.Assert().False(bytes.Contains(content, []byte(s.otherOrg.ID)), "bundle file %s references another org", name)
+	}
+}
@@ -0,0 +1,333 @@
+package main_suite_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	cassetteRecord = "record"
+	cassetteReplay = "replay"
+
+	// Larger request bodies are only kept as a digest, to match them on replay.
+	cassetteMaxBody = 64 << 10
+)
+
+var (
+	uuidPattern      = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	timestampPattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})?`)
+	passwordPattern  = regexp.MustCompile(`password=[^&]*`)
+	tokenPattern     = regexp.MustCompile(`"(access_token|refresh_token|id_token|token)":"[^"]*"`)
+)
+
+// scrub keeps passwords, tokens and the super admin of the environment out
+// of cassettes, so they can be committed and replayed anywhere.
+func scrub(s string) string {
+	s = passwordPattern.ReplaceAllString(s, "password={redacted}")
+	s = tokenPattern.ReplaceAllString(s, `"$1":"{redacted}"`)
+
+	for placeholder, v := range map[string]string{
+		"{superAdminOrgID}": config.superAdminOrgID,
+		"{superAdminEmail}": config.superAdminEmail,
+	} {
+		if v != "" {
+			s = strings.ReplaceAll(s, url.QueryEscape(v), placeholder)
+			s = strings.ReplaceAll(s, v, placeholder)
+		}
+	}
+
+	return s
+}
+
+// normalizeVolatile replaces the ids and timestamps that differ from one run
+// to the other, so a replayed request matches the one that was recorded.
+func normalizeVolatile(s string) string {
+	s = uuidPattern.ReplaceAllString(s, "{id}")
+	return timestampPattern.ReplaceAllString(s, "{timestamp}")
+}
+
+func bodyKey(body string) string {
+	body = normalizeVolatile(scrub(body))
+	if len(body) > cassetteMaxBody {
+		return "sha256:" + checksum([]byte(body))
+	}
+
+	return body
+}
+
+// interaction is one recorded request and the response it got.
+type interaction struct {
+	Seq            int    `json:"seq"`
+	Method         string `json:"method"`
+	URL            string `json:"url"`
+	RequestBody    string `json:"requestBody,omitempty"`
+	RequestDigest  string `json:"requestDigest,omitempty"`
+	Status         int    `json:"status"`
+	ContentType    string `json:"contentType,omitempty"`
+	ResponseBody   string `json:"responseBody,omitempty"`
+	ResponseBase64 []byte `json:"responseBase64,omitempty"`
+}
+
+func (i *interaction) key() string {
+	body := i.RequestDigest
+	if body == "" {
+		body = bodyKey(i.RequestBody)
+	}
+
+	return i.Method + " " + normalizeVolatile(scrub(i.URL)) + "\n" + body
+}
+
+func (i *interaction) response(req *http.Request, rebind func(string) string) *http.Response {
+	body := i.ResponseBase64
+	if body == nil {
+		body = []byte(rebind(i.ResponseBody))
+	}
+
+	header := http.Header{}
+	if i.ContentType != "" {
+		header.Set("Content-Type", i.ContentType)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", i.Status, http.StatusText(i.Status)),
+		StatusCode:    i.Status,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// cassette holds the interactions of one test, in the order they were sent.
+// On replay, times maps the timestamps sent while recording to the ones the
+// test sends now, so responses echo what the test expects.
+type cassette struct {
+	name         string
+	interactions []*interaction
+	used         []bool
+	times        map[int64]time.Time
+}
+
+func (c *cassette) bind(recorded, live string) {
+	r, l := timestampPattern.FindAllString(recorded, -1), timestampPattern.FindAllString(live, -1)
+	if len(r) != len(l) {
+		return
+	}
+
+	for n := range r {
+		rt, err := time.Parse(time.RFC3339, r[n])
+		if err != nil {
+			continue
+		}
+
+		if lt, err := time.Parse(time.RFC3339, l[n]); err == nil {
+			c.times[rt.UnixMilli()] = lt
+		}
+	}
+}
+
+func (c *cassette) rebind(s string) string {
+	return timestampPattern.ReplaceAllStringFunc(s, func(ts string) string {
+		t, err := time.Parse(time.RFC3339, ts)
+		if err != nil {
+			return ts
+		}
+
+		if live, ok := c.times[t.UnixMilli()]; ok {
+			return formatEnrollmentTime(live)
+		}
+
+		return ts
+	})
+}
+
+// cassettePlayer records every request of the suite to a cassette per test
+// with CASSETTE_MODE=record, and serves them back with CASSETTE_MODE=replay.
+type cassettePlayer struct {
+	mu      sync.Mutex
+	mode    string
+	dir     string
+	current *cassette
+}
+
+var cassettes *cassettePlayer
+
+func newCassettePlayer(mode, dir string) (*cassettePlayer, error) {
+	if mode != cassetteRecord && mode != cassetteReplay {
+		return nil, fmt.Errorf("invalid cassette mode %q, expected %s or %s", mode, cassetteRecord, cassetteReplay)
+	}
+
+	return &cassettePlayer{mode: mode, dir: dir}, nil
+}
+
+func (p *cassettePlayer) path(name string) string {
+	return filepath.Join(p.dir, strings.NewReplacer("/", "_", " ", "_").Replace(name)+".json")
+}
+
+// insert switches to the cassette of name, saving the previous one.
+func (p *cassettePlayer) insert(name string) error {
+	if err := p.eject(); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	c := &cassette{name: name, times: map[int64]time.Time{}}
+	if p.mode == cassetteReplay {
+		b, err := os.ReadFile(p.path(name))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+
+		if err == nil {
+			if err = json.Unmarshal(b, &c.interactions); err != nil {
+				return fmt.Errorf("could not decode cassette %s: %w", name, err)
+			}
+		}
+		slices.SortStableFunc(c.interactions, func(a, b *interaction) int { return a.Seq - b.Seq })
+		c.used = make([]bool, len(c.interactions))
+	}
+
+	p.current = c
+	return nil
+}
+
+// eject saves the current cassette when recording.
+func (p *cassettePlayer) eject() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	c := p.current
+	p.current = nil
+	if c == nil || p.mode != cassetteRecord {
+		return nil
+	}
+
+	b, err := json.MarshalIndent(c.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(p.dir, 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(p.path(c.name), b, 0644)
+}
+
+func (p *cassettePlayer) record(req *http.Request, reqBody []byte, resp *http.Response, respBody []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.current == nil {
+		return
+	}
+
+	i := &interaction{
+		Seq:         len(p.current.interactions),
+		Method:      req.Method,
+		URL:         scrub(req.URL.RequestURI()),
+		RequestBody: scrub(string(reqBody)),
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+
+	if key := bodyKey(i.RequestBody); strings.HasPrefix(key, "sha256:") {
+		i.RequestBody, i.RequestDigest = "", key
+	}
+
+	if utf8.Valid(respBody) {
+		i.ResponseBody = scrub(string(respBody))
+	} else {
+		i.ResponseBase64 = respBody
+	}
+
+	p.current.interactions = append(p.current.interactions, i)
+}
+
+// replay serves the interactions in the order of their Seq: req must match
+// the first one not served yet. Copies of a request sent concurrently match
+// it whatever order they arrive in, as they share its key.
+func (p *cassettePlayer) replay(req *http.Request, reqBody []byte) (*http.Response, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	c := p.current
+	if c == nil {
+		return nil, fmt.Errorf("no cassette inserted for %s %s", req.Method, req.URL.RequestURI())
+	}
+
+	n := slices.Index(c.used, false)
+	if n < 0 {
+		return nil, fmt.Errorf("no recorded interaction left in cassette %s for %s %s", c.name, req.Method, req.URL.RequestURI())
+	}
+
+	i := c.interactions[n]
+	key := (&interaction{Method: req.Method, URL: req.URL.RequestURI(), RequestBody: string(reqBody)}).key()
+	if expected := i.key(); expected != key {
+		return nil, fmt.Errorf("cassette %s expected interaction %d:\n%s\ngot:\n%s", c.name, i.Seq, expected, key)
+	}
+
+	c.used[n] = true
+	c.bind(i.URL+i.RequestBody, scrub(req.URL.RequestURI()+string(reqBody)))
+	return i.response(req, c.rebind), nil
+}
+
+// doRequest sends req, through the cassette player when one is configured,
+// timing it while a load runs.
+func doRequest(req *http.Request) (*http.Response, error) {
+	if recorder := load.Load(); recorder != nil {
+		return recorder.time(req, roundTrip)
+	}
+
+	return roundTrip(req)
+}
+
+func roundTrip(req *http.Request) (*http.Response, error) {
+	if cassettes == nil {
+		return http.DefaultClient.Do(req)
+	}
+
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	if cassettes.mode == cassetteReplay {
+		return cassettes.replay(req, reqBody)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return resp, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return resp, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	cassettes.record(req, reqBody, resp, respBody)
+	return resp, nil
+}
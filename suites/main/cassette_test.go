@@ -0,0 +1,48 @@
+package main_suite_test
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runTestBinary runs this test binary again with args, in an environment
+// cleared of the settings of the current run but for env, and returns its
+// output.
+func runTestBinary(t *testing.T, env []string, args ...string) (string, error) {
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(),
+		"FAKE_PLATFORM=", "CASSETTE_MODE=", "PARALLEL=", "DB_SNAPSHOT=", "LOAD_LEARNERS=", "LOAD_REPORT=",
+		"CONTRACT_CHECK=", "LATENCY_CHECK=", "COVERAGE_REPORT=", "REPORT_DIR=")
+	cmd.Env = append(cmd.Env, env...)
+
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+// TestReplaySingleSuite records several suites against the fake platform,
+// then replays suites and tests alone: what they send must not depend on
+// the suites that ran before them while recording.
+func TestReplaySingleSuite(t *testing.T) {
+	// The runs below set CASSETTE_MODE themselves.
+	if os.Getenv("CASSETTE_MODE") != "" || testing.Short() {
+		t.Skip("records and replays its own cassettes")
+	}
+
+	dir := t.TempDir()
+	out, err := runTestBinary(t, []string{"FAKE_PLATFORM=true", "CASSETTE_MODE=record", "CASSETTE_DIR=" + dir},
+		"-test.run", "^Test(Identity|LearningPlan|Offline)Suite$")
+	require.Nil(t, err, out)
+
+	for _, args := range [][]string{
+		{"-test.run", "^TestOfflineSuite$"},
+		{"-test.run", "^TestLearningPlanSuite$", "-testify.m", "^TestWorldBuilder$"},
+		{"-test.run", "^TestIdentitySuite$"},
+	} {
+		out, err := runTestBinary(t, []string{"CASSETTE_MODE=replay", "CASSETTE_DIR=" + dir}, args...)
+		assert.Nil(t, err, "%v:\n%s", args, out)
+	}
+}
@@ -8,11 +8,43 @@ type cnf struct {
 	superAdminPassword string
 
 	apiGatewayURL string
+	keycloakURL   string
+	contextsURL   string
 
 	dbUser     string
 	dbPassword string
 	dbHost     string
 	dbPort     string
+
+	bundleDir     string
+	bundleBaseURL string
+
+	dbSnapshot       string
+	dbSnapshotTables string
+
+	contractCheck string
+	contractSpec  string
+
+	coverageReport string
+	routeInventory string
+
+	latencyCheck   string
+	latencyBudgets string
+
+	reportDir string
+
+	fakePlatform bool
+
+	cassetteMode string
+	cassetteDir  string
+
+	parallel string
+
+	loadLearners string
+	loadRampUp   string
+	loadDuration string
+	loadSyncs    string
+	loadReport   string
 }
 
 var config *cnf
@@ -27,9 +59,46 @@ func loadConfig() {
 		superAdminEmail:    os.Getenv("SUPER_ADMIN_EMAIL"),
 		superAdminPassword: os.Getenv("SUPER_ADMIN_PASSWORD"),
 		apiGatewayURL:      os.Getenv("API_GATEWAY_URL"),
+		keycloakURL:        getenv("KEYCLOAK_URL", "http://localhost:8080"),
+		contextsURL:        getenv("CONTEXTS_URL", "http://localhost:8050"),
 		dbUser:             os.Getenv("DB_USER"),
 		dbPassword:         os.Getenv("DB_PASSWORD"),
 		dbHost:             os.Getenv("DB_HOST"),
 		dbPort:             os.Getenv("DB_PORT"),
+		bundleDir:          os.Getenv("BUNDLE_DIR"),
+		bundleBaseURL:      os.Getenv("BUNDLE_BASE_URL"),
+		dbSnapshot:         os.Getenv("DB_SNAPSHOT"),
+		dbSnapshotTables:   os.Getenv("DB_SNAPSHOT_TABLES"),
+		contractCheck:      os.Getenv("CONTRACT_CHECK"),
+		contractSpec:       getenv("CONTRACT_SPEC", "./testdata/openapi.yaml"),
+		coverageReport:     os.Getenv("COVERAGE_REPORT"),
+		routeInventory:     getenv("ROUTE_INVENTORY", "./testdata/routes.yaml"),
+		latencyCheck:       os.Getenv("LATENCY_CHECK"),
+		latencyBudgets:     getenv("LATENCY_BUDGETS", "./testdata/latency.yaml"),
+		reportDir:          os.Getenv("REPORT_DIR"),
+		fakePlatform:       os.Getenv("FAKE_PLATFORM") == "true",
+		cassetteMode:       os.Getenv("CASSETTE_MODE"),
+		cassetteDir:        getenv("CASSETTE_DIR", "./testdata/cassettes"),
+		parallel:           os.Getenv("PARALLEL"),
+		loadLearners:       os.Getenv("LOAD_LEARNERS"),
+		loadRampUp:         getenv("LOAD_RAMP_UP", "30s"),
+		loadDuration:       getenv("LOAD_DURATION", "5m"),
+		loadSyncs:          getenv("LOAD_SYNCS", "3"),
+		loadReport:         os.Getenv("LOAD_REPORT"),
+	}
+
+	// Replays match the super admin against a placeholder, any value will do.
+	if config.fakePlatform || config.cassetteMode == cassetteReplay {
+		config.superAdminOrgID = getenv("SUPER_ADMIN_ORG_ID", fakeSuperAdminOrgID)
+		config.superAdminEmail = getenv("SUPER_ADMIN_EMAIL", "superadmin@learntowin.com")
+		config.superAdminPassword = getenv("SUPER_ADMIN_PASSWORD", "password")
 	}
 }
+
+func getenv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+
+	return fallback
+}
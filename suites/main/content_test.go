@@ -0,0 +1,82 @@
+package main_suite_test
+
+import (
+	"testing"
+)
+
+// ContentSuite covers course authoring, with a draft course holding a lesson
+// and a quiz.
+type ContentSuite struct {
+	harness
+
+	org          *organization
+	orgAdmin     userCredentials
+	orgAdminInfo *user
+
+	course      *course
+	lesson      *learningItem
+	quiz        *learningItem
+	lessonCards []*card
+	quizCards   []*card
+}
+
+func (s *ContentSuite) SetupSuite() {
+	s.harness.SetupSuite()
+
+	b := newWorldBuilder()
+	school := b.org("school")
+	school.admin("admin")
+	school.course("geography").titled("Geography").draft().lesson("lesson").quiz("quiz")
+
+	w := s.setupWorld(b)
+	s.org, s.orgAdmin, s.orgAdminInfo = w.org("school"), w.as("admin"), w.user("admin")
+	s.homeOrg = s.org
+	s.course, s.lesson, s.quiz = w.course("geography"), w.item("lesson"), w.item("quiz")
+	s.lessonCards, s.quizCards = w.cards("lesson"), w.cards("quiz")
+}
+
+func TestContentSuite(t *testing.T) {
+	runSuite[ContentSuite](t)
+}
+
+// TestCourseCards checks the cards created from the card files of the lesson
+// and the quiz, as the former suite setup did.
+func (s *ContentSuite) TestCourseCards() {
+	for _, tc := range []struct {
+		item  *learningItem
+		cards []*card
+		n     int
+	}{
+		{s.lesson, s.lessonCards, 3},
+		{s.quiz, s.quizCards, 6},
+	} {
+		s.Require().Equal(tc.n, len(tc.cards), "cards of learning item %s", tc.item.ID)
+
+		ids := map[string]bool{}
+		for _, c := range tc.cards {
+			s.Assert().NotEmpty(c.ID)
+			ids[c.ID] = true
+		}
+		s.Assert().Equal(tc.n, len(ids), "distinct cards of learning item %s", tc.item.ID)
+	}
+}
+
+// TestCreateLearningItem adds a lesson to the draft course, then cards from
+// the lesson card file.
+func (s *ContentSuite) TestCreateLearningItem() {
+	lesson, err := s.apiClient.createLearningItem(createLearningItemRequest{
+		Course:        newIRI("courses", s.course.ID),
+		Type:          "lesson",
+		State:         "draft",
+		Name:          "Extra lesson",
+		Points:        1,
+		SequenceOrder: 3,
+	}, s.orgAdmin)
+	s.Require().Nil(err)
+	s.Assert().NotEmpty(lesson.ID)
+	s.Assert().NotEqual(s.lesson.ID, lesson.ID)
+
+	cards, err := s.apiClient.createCardsFromFile(lesson.ID, "./testdata/cards.json", s.orgAdmin)
+	s.Require().Nil(err)
+	s.Assert().Equal(3, len(cards))
+}
@@ -0,0 +1,367 @@
+package main_suite_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	contractCheckReport = "report"
+	contractCheckFail   = "fail"
+)
+
+// schema is the subset of OpenAPI schema objects the gateway spec uses.
+type schema struct {
+	Ref                  string             `yaml:"$ref"`
+	Type                 string             `yaml:"type"`
+	Nullable             bool               `yaml:"nullable"`
+	Properties           map[string]*schema `yaml:"properties"`
+	Required             []string           `yaml:"required"`
+	Items                *schema            `yaml:"items"`
+	AdditionalProperties any                `yaml:"additionalProperties"`
+	Enum                 []string           `yaml:"enum"`
+}
+
+// closed reports whether fields missing from Properties are undocumented.
+func (s *schema) closed() bool {
+	allowed, ok := s.AdditionalProperties.(bool)
+	return len(s.Properties) > 0 && (s.AdditionalProperties == nil || (ok && !allowed))
+}
+
+type mediaType struct {
+	Schema *schema `yaml:"schema"`
+}
+
+type apiResponse struct {
+	Description string               `yaml:"description"`
+	Content     map[string]mediaType `yaml:"content"`
+}
+
+type operation struct {
+	OperationID string                  `yaml:"operationId"`
+	Responses   map[string]*apiResponse `yaml:"responses"`
+}
+
+type pathItem struct {
+	Get    *operation `yaml:"get"`
+	Post   *operation `yaml:"post"`
+	Put    *operation `yaml:"put"`
+	Patch  *operation `yaml:"patch"`
+	Delete *operation `yaml:"delete"`
+}
+
+func (p *pathItem) operation(method string) *operation {
+	switch method {
+	case http.MethodGet:
+		return p.Get
+	case http.MethodPost:
+		return p.Post
+	case http.MethodPut:
+		return p.Put
+	case http.MethodPatch:
+		return p.Patch
+	case http.MethodDelete:
+		return p.Delete
+	}
+
+	return nil
+}
+
+type openAPISpec struct {
+	Paths      map[string]*pathItem `yaml:"paths"`
+	Components struct {
+		Schemas map[string]*schema `yaml:"schemas"`
+	} `yaml:"components"`
+}
+
+func loadOpenAPISpec(path string) (*openAPISpec, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var spec openAPISpec
+	if err = yaml.Unmarshal(b, &spec); err != nil {
+		return nil, fmt.Errorf("could not decode %s: %w", path, err)
+	}
+
+	return &spec, nil
+}
+
+// route returns the templated path matching path, literal segments winning
+// over parameters, e.g. /v1/enrollments/sync over /v1/enrollments/{id}.
+func (spec *openAPISpec) route(method, path string) (string, *operation) {
+	var templates []string
+	for template, item := range spec.Paths {
+		if item.operation(method) != nil {
+			templates = append(templates, template)
+		}
+	}
+
+	best, ok := matchTemplate(templates, path)
+	if !ok {
+		return "", nil
+	}
+
+	return best, spec.Paths[best].operation(method)
+}
+
+// matchTemplate returns the template matching path with the fewest parameters,
+// the lowest in byte order on a tie. As "{" sorts after the letters, that is
+// the template with a literal segment first where the others have a parameter.
+func matchTemplate(templates []string, path string) (string, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	var best string
+	bestParams := -1
+	for _, template := range templates {
+		parts := strings.Split(strings.Trim(template, "/"), "/")
+		if len(parts) != len(segments) {
+			continue
+		}
+
+		params := 0
+		for i, part := range parts {
+			if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+				params += 1
+				continue
+			}
+
+			if part != segments[i] {
+				params = -1
+				break
+			}
+		}
+
+		if params < 0 {
+			continue
+		}
+		if bestParams < 0 || params < bestParams || params == bestParams && template < best {
+			best, bestParams = template, params
+		}
+	}
+
+	return best, bestParams >= 0
+}
+
+// response returns the documented response for status: the exact code first,
+// then its range (2XX) and finally the default response.
+func (op *operation) response(status int) *apiResponse {
+	for _, key := range []string{fmt.Sprint(status), fmt.Sprintf("%dXX", status/100), "default"} {
+		if r, ok := op.Responses[key]; ok {
+			return r
+		}
+	}
+
+	return nil
+}
+
+// resolve follows the $ref of s, returning the ref it could not find in the
+// components of the spec if any.
+func (spec *openAPISpec) resolve(s *schema) (*schema, string) {
+	for s != nil && s.Ref != "" {
+		ref := s.Ref
+		if s = spec.Components.Schemas[strings.TrimPrefix(ref, "#/components/schemas/")]; s == nil {
+			return nil, ref
+		}
+	}
+
+	return s, ""
+}
+
+// validate returns the contract violations of v, decoded with UseNumber, at path.
+func (spec *openAPISpec) validate(s *schema, v any, path string) []string {
+	s, unresolved := spec.resolve(s)
+	if unresolved != "" {
+		return []string{fmt.Sprintf("%s: unresolved $ref %s", path, unresolved)}
+	}
+	if s == nil {
+		return nil
+	}
+
+	if v == nil {
+		if s.Nullable {
+			return nil
+		}
+		return []string{fmt.Sprintf("%s: null where %s is expected", path, s.Type)}
+	}
+
+	mismatch := func(got string) []string {
+		return []string{fmt.Sprintf("%s: %s where %s is expected", path, got, s.Type)}
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		if s.Type != "" && s.Type != "object" {
+			return mismatch("object")
+		}
+
+		var violations []string
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				violations = append(violations, fmt.Sprintf("%s: missing required field %s", path, name))
+			}
+		}
+
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			prop, ok := s.Properties[name]
+			if !ok {
+				if s.closed() {
+					violations = append(violations, fmt.Sprintf("%s: undocumented field %s", path, name))
+				}
+				continue
+			}
+
+			violations = append(violations, spec.validate(prop, v[name], path+"."+name)...)
+		}
+
+		return violations
+	case []any:
+		if s.Type != "" && s.Type != "array" {
+			return mismatch("array")
+		}
+
+		var violations []string
+		for i, item := range v {
+			violations = append(violations, spec.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+
+		return violations
+	case string:
+		if s.Type != "" && s.Type != "string" {
+			return mismatch("string")
+		}
+
+		if len(s.Enum) > 0 && !slices.Contains(s.Enum, v) {
+			return []string{fmt.Sprintf("%s: %q is not one of %s", path, v, strings.Join(s.Enum, ", "))}
+		}
+	case json.Number:
+		if _, err := v.Int64(); s.Type == "integer" && err != nil {
+			return mismatch("number")
+		}
+
+		if s.Type != "" && s.Type != "integer" && s.Type != "number" {
+			return mismatch("number")
+		}
+	case bool:
+		if s.Type != "" && s.Type != "boolean" {
+			return mismatch("boolean")
+		}
+	}
+
+	return nil
+}
+
+// contractViolation is a gateway response that does not match the spec.
+type contractViolation struct {
+	method  string
+	route   string
+	status  int
+	message string
+}
+
+func (v contractViolation) String() string {
+	return fmt.Sprintf("%s %s (%d): %s", v.method, v.route, v.status, v.message)
+}
+
+// contractChecker validates every gateway response against the OpenAPI spec
+// and keeps the violations by test until the test collects them.
+type contractChecker struct {
+	mu         sync.Mutex
+	spec       *openAPISpec
+	violations map[string][]contractViolation
+}
+
+var contracts *contractChecker
+
+func newContractChecker(specPath string) (*contractChecker, error) {
+	spec, err := loadOpenAPISpec(specPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &contractChecker{spec: spec, violations: map[string][]contractViolation{}}, nil
+}
+
+// gatewayPath returns the path of req relative to the gateway, false when
+// req goes to another service.
+func gatewayPath(req *http.Request) (string, bool) {
+	if !strings.HasPrefix(req.URL.String(), config.apiGatewayURL) {
+		return "", false
+	}
+
+	path, _, _ := strings.Cut(strings.TrimPrefix(req.URL.String(), config.apiGatewayURL), "?")
+	return path, true
+}
+
+func (c *contractChecker) check(test string, req *http.Request, status int, body []byte) {
+	path, ok := gatewayPath(req)
+	if !ok {
+		return
+	}
+
+	route, op := c.spec.route(req.Method, path)
+	if op == nil {
+		c.record(test, contractViolation{req.Method, path, status, "undocumented route"})
+		return
+	}
+
+	resp := op.response(status)
+	if resp == nil {
+		c.record(test, contractViolation{req.Method, route, status, fmt.Sprintf("undocumented status %d", status)})
+		return
+	}
+
+	var violations []string
+	for _, content := range resp.Content {
+		if content.Schema == nil {
+			continue
+		}
+
+		var v any
+		d := json.NewDecoder(bytes.NewReader(body))
+		d.UseNumber()
+		if err := d.Decode(&v); err != nil {
+			violations = []string{"response is not JSON: " + err.Error()}
+		} else {
+			violations = c.spec.validate(content.Schema, v, "$")
+		}
+		break
+	}
+
+	for _, msg := range violations {
+		c.record(test, contractViolation{req.Method, route, status, msg})
+	}
+}
+
+func (c *contractChecker) record(test string, v contractViolation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.violations[test] = append(c.violations[test], v)
+}
+
+// drain returns the violations of test found since the last call.
+func (c *contractChecker) drain(test string) []contractViolation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	violations := c.violations[test]
+	delete(c.violations, test)
+	return violations
+}
@@ -0,0 +1,119 @@
+package main_suite_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const contractTestSpec = `
+paths:
+  /v1/courses/{id}:
+    get:
+      responses:
+        "200":
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Course"
+  /v1/courses/{id}/cards:
+    get:
+      responses:
+        "200":
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Missing"
+components:
+  schemas:
+    Course:
+      type: object
+      required: [id, title]
+      properties:
+        id:
+          type: string
+        title:
+          type: string
+        points:
+          type: integer
+        archivedAt:
+          type: string
+          nullable: true
+`
+
+func newTestContractChecker(t *testing.T) *contractChecker {
+	var spec openAPISpec
+	require.Nil(t, yaml.Unmarshal([]byte(contractTestSpec), &spec))
+
+	return &contractChecker{spec: &spec, violations: map[string][]contractViolation{}}
+}
+
+func TestContractValidate(t *testing.T) {
+	c := newTestContractChecker(t)
+	course := &schema{Ref: "#/components/schemas/Course"}
+
+	for _, tc := range []struct {
+		name       string
+		body       map[string]any
+		violations []string
+	}{
+		{"valid", map[string]any{"id": "1", "title": "Geography"}, nil},
+		{"missing required field", map[string]any{"id": "1"}, []string{"$: missing required field title"}},
+		{"type mismatch", map[string]any{"id": "1", "title": "Geography", "points": "ten"}, []string{"$.points: string where integer is expected"}},
+		{"undocumented field", map[string]any{"id": "1", "title": "Geography", "state": "draft"}, []string{"$: undocumented field state"}},
+		{"nullable", map[string]any{"id": "1", "title": "Geography", "archivedAt": nil}, nil},
+		{"null where not nullable", map[string]any{"id": "1", "title": nil}, []string{"$.title: null where string is expected"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.violations, c.spec.validate(course, tc.body, "$"))
+		})
+	}
+
+	t.Run("unresolved ref", func(t *testing.T) {
+		violations := c.spec.validate(&schema{Ref: "#/components/schemas/Missing"}, map[string]any{}, "$")
+		assert.Equal(t, []string{"$: unresolved $ref #/components/schemas/Missing"}, violations)
+	})
+}
+
+func TestContractCheck(t *testing.T) {
+	defer func(c *cnf) { config = c }(config)
+	config = &cnf{apiGatewayURL: "http://gateway"}
+
+	for _, tc := range []struct {
+		name      string
+		method    string
+		path      string
+		status    int
+		body      string
+		violation string
+	}{
+		{"undocumented route", http.MethodGet, "/v1/courses", 200, `[]`, "GET /v1/courses (200): undocumented route"},
+		{"undocumented status", http.MethodGet, "/v1/courses/1", 409, `{}`, "GET /v1/courses/{id} (409): undocumented status 409"},
+		{"unresolved ref", http.MethodGet, "/v1/courses/1/cards", 200, `{}`, "GET /v1/courses/{id}/cards (200): $: unresolved $ref #/components/schemas/Missing"},
+		{"not JSON", http.MethodGet, "/v1/courses/1", 200, `<html>`, "GET /v1/courses/{id} (200): response is not JSON: invalid character '<' looking for beginning of value"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestContractChecker(t)
+			req, err := http.NewRequest(tc.method, config.apiGatewayURL+tc.path, nil)
+			require.Nil(t, err)
+
+			c.check(t.Name(), req, tc.status, []byte(tc.body))
+
+			violations := c.drain(t.Name())
+			require.Len(t, violations, 1)
+			assert.Equal(t, tc.violation, violations[0].String())
+		})
+	}
+
+	t.Run("other service", func(t *testing.T) {
+		c := newTestContractChecker(t)
+		req, err := http.NewRequest(http.MethodPost, "http://keycloak/realms/master/protocol/openid-connect/token", nil)
+		require.Nil(t, err)
+
+		c.check(t.Name(), req, 200, []byte(`{}`))
+		assert.Empty(t, c.drain(t.Name()))
+	})
+}
@@ -0,0 +1,140 @@
+package main_suite_test
+
+import (
+	"fmt"
+	"time"
+)
+
+// courseSuite is embedded by the suites working on a published course: an
+// org whose Blue learner is invited to a geography course by a learning plan,
+// and another org with its own admin.
+type courseSuite struct {
+	harness
+
+	org        *organization
+	otherOrg   *organization
+	course     *course
+	otherAdmin userCredentials
+	orgAdmin   userCredentials
+	learner    userCredentials
+
+	orgAdminInfo   *user
+	learnerInfo    *user
+	otherAdminInfo *user
+
+	learningPlan     *learningPlan
+	learningGroup    *learningGroup
+	colorAttributeID string
+
+	lesson      *learningItem
+	quiz        *learningItem
+	lessonCards []*card
+	quizCards   []*card
+	scoring     *scoringOracle
+	enrollments enrollmentReader
+}
+
+func courseWorld() *worldBuilder {
+	b := newWorldBuilder()
+	school := b.org("school").named("atg")
+	school.admin("admin")
+	school.learner("learner").attribute("color", "Blue")
+	school.attribute("color", "Red", "Green", "Blue")
+	school.group("blue").filter("color", "EQ", "Blue")
+	school.course("geography").titled("Geography").lesson("lesson").quiz("quiz")
+	school.plan("semester").courses("geography").groups("blue")
+
+	b.org("other").named("Other Org").admin("otherAdmin")
+
+	return b
+}
+
+func (s *courseSuite) SetupSuite() {
+	s.harness.SetupSuite()
+
+	w := s.setupWorld(courseWorld())
+	s.org, s.otherOrg = w.org("school"), w.org("other")
+	s.homeOrg = s.org
+	s.orgAdmin, s.learner, s.otherAdmin = w.as("admin"), w.as("learner"), w.as("otherAdmin")
+	s.orgAdminInfo, s.learnerInfo, s.otherAdminInfo = w.user("admin"), w.user("learner"), w.user("otherAdmin")
+	s.learningPlan, s.learningGroup, s.colorAttributeID = w.plan("semester"), w.group("blue"), w.id("color")
+	s.course, s.lesson, s.quiz = w.course("geography"), w.item("lesson"), w.item("quiz")
+	s.lessonCards, s.quizCards = w.cards("lesson"), w.cards("quiz")
+
+	s.Require().Equal(1, s.learningGroup.UserCount)
+	s.Require().Equal(3, len(s.lessonCards))
+	s.Require().Equal(6, len(s.quizCards))
+
+	definitions, err := loadQuizCards("./testdata/quiz.json")
+	s.Require().Nil(err)
+	s.scoring, err = newScoringOracle(definitions, s.quizCards)
+	s.Require().Nil(err)
+
+	s.enrollments = newEnrollmentReader(s.db, fake)
+}
+
+// newLearner creates a learner in the Blue learning group and logs them in.
+func (s *courseSuite) newLearner(name string) (*user, userCredentials) {
+	info, err := s.apiClient.createUser(createUserRequest{
+		Email:     fmt.Sprintf("dborry+e2e_%s@learntowin.com", name),
+		FirstName: "David",
+		LastName:  "Borry",
+		Roles:     []string{"ROLE_LEARNER"},
+	}, s.orgAdmin)
+	s.Require().Nil(err)
+	s.Require().NotEmpty(info.ID)
+
+	err = s.apiClient.assignUserAttributes(assignUserAttributesRequest{
+		UserID:      info.ID,
+		AttributeID: s.colorAttributeID,
+		Value:       "Blue",
+	}, s.orgAdmin)
+	s.Require().Nil(err)
+
+	credentials, err := userLogin(info.Email, "password", s.org.ID, true)
+	s.Require().Nil(err)
+
+	return info, credentials
+}
+
+// waitForInvitation waits for a learning plan to invite userID to the course.
+func (s *courseSuite) waitForInvitation(courseID, userID string) *invitation {
+	return s.waitForInvitationIn(s.orgAdmin, courseID, userID)
+}
+
+// setupExtraCourse publishes a one lesson course in its own learning plan
+// for the Blue group, for tests that must not alter the suite's course.
+func (s *courseSuite) setupExtraCourse(title string) *course {
+	course, err := s.apiClient.createCourse(createCourseRequest{OrganizationId: s.org.ID, VersionName: title, Title: title}, s.orgAdmin)
+	s.Require().Nil(err)
+	s.Require().NotEmpty(course.ID)
+
+	lesson, err := s.apiClient.createLearningItem(createLearningItemRequest{
+		Course: newIRI("courses", course.ID),
+		Type:   "lesson",
+		State:  "draft",
+		Name:   title,
+		Points: 1,
+	}, s.orgAdmin)
+	s.Require().Nil(err)
+
+	_, err = s.apiClient.createCardsFromFile(lesson.ID, "./testdata/cards.json", s.orgAdmin)
+	s.Require().Nil(err)
+
+	plan, err := s.apiClient.createLearningPlan(createLearningPlanRequest{Name: title, ActivatedAt: time.Now().Format(time.RFC3339)}, s.orgAdmin)
+	s.Require().Nil(err)
+
+	_, err = s.apiClient.addCoursesToLearningPlan(plan.ID, addCoursesToLearningPlanRequest{Courses: []string{course.ID}}, s.orgAdmin)
+	s.Require().Nil(err)
+
+	_, err = s.apiClient.addGroupsToLearningPlan(plan.ID, addGroupsToLearningPlanRequest{LearningGroupIDs: []string{s.learningGroup.ID}}, s.orgAdmin)
+	s.Require().Nil(err)
+
+	_, err = s.apiClient.activateCourse(course.ID, s.orgAdmin)
+	s.Require().Nil(err)
+
+	_, err = s.apiClient.activateLearningPlan(plan.ID, s.orgAdmin)
+	s.Require().Nil(err)
+
+	return course
+}
@@ -0,0 +1,236 @@
+package main_suite_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// inventoryRoute is a gateway route with the status classes it can answer.
+type inventoryRoute struct {
+	Route    string   `yaml:"route"`
+	Statuses []string `yaml:"statuses"`
+}
+
+// routeInventory is the list of gateway routes in ROUTE_INVENTORY.
+type routeInventory []inventoryRoute
+
+// gatewayRoutes templates the endpoints of the coverage, latency, load and
+// run reports.
+var gatewayRoutes routeInventory
+
+func loadRouteInventory(path string) (routeInventory, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var routes routeInventory
+	if err = yaml.Unmarshal(b, &routes); err != nil {
+		return nil, fmt.Errorf("could not decode %s: %w", path, err)
+	}
+
+	return routes, nil
+}
+
+func statusClass(status int) string {
+	return fmt.Sprintf("%dxx", status/100)
+}
+
+// coverageRecorder counts the gateway calls of every test by route and
+// status class, the route being templated against the inventory.
+type coverageRecorder struct {
+	mu        sync.Mutex
+	inventory routeInventory
+	routes    map[string]map[string]int
+	tests     map[string]map[string]int
+}
+
+var coverage *coverageRecorder
+
+func newCoverageRecorder(inventory routeInventory) *coverageRecorder {
+	return &coverageRecorder{
+		inventory: inventory,
+		routes:    map[string]map[string]int{},
+		tests:     map[string]map[string]int{},
+	}
+}
+
+// route returns the inventory route of a call, or its path with the ids
+// replaced when the inventory does not have it.
+func (inv routeInventory) route(method, path string) string {
+	var templates []string
+	for _, r := range inv {
+		if m, template, _ := strings.Cut(r.Route, " "); m == method {
+			templates = append(templates, template)
+		}
+	}
+
+	if template, ok := matchTemplate(templates, path); ok {
+		return method + " " + template
+	}
+
+	return method + " " + uuidPattern.ReplaceAllString(path, "{id}")
+}
+
+func (c *coverageRecorder) record(test string, req *http.Request, status int) {
+	path, ok := gatewayPath(req)
+	if !ok {
+		return
+	}
+
+	route := c.inventory.route(req.Method, path)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.routes[route] == nil {
+		c.routes[route] = map[string]int{}
+	}
+	c.routes[route][statusClass(status)] += 1
+
+	if c.tests[test] == nil {
+		c.tests[test] = map[string]int{}
+	}
+	c.tests[test][route] += 1
+}
+
+type routeCoverage struct {
+	Route            string         `json:"route"`
+	Calls            int            `json:"calls"`
+	Statuses         map[string]int `json:"statuses"`
+	UntestedStatuses []string       `json:"untestedStatuses,omitempty"`
+}
+
+type coverageReport struct {
+	Covered        []routeCoverage           `json:"covered"`
+	UntestedRoutes []string                  `json:"untestedRoutes"`
+	UnknownRoutes  []routeCoverage           `json:"unknownRoutes"`
+	Tests          map[string]map[string]int `json:"tests"`
+}
+
+func (c *coverageRecorder) report() *coverageReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	report := &coverageReport{Tests: c.tests}
+	known := map[string]bool{}
+	for _, r := range c.inventory {
+		known[r.Route] = true
+
+		statuses, ok := c.routes[r.Route]
+		if !ok {
+			report.UntestedRoutes = append(report.UntestedRoutes, r.Route)
+			continue
+		}
+
+		rc := routeCoverage{Route: r.Route, Statuses: statuses}
+		for _, class := range r.Statuses {
+			if statuses[class] == 0 {
+				rc.UntestedStatuses = append(rc.UntestedStatuses, class)
+			}
+		}
+
+		for _, n := range statuses {
+			rc.Calls += n
+		}
+		report.Covered = append(report.Covered, rc)
+	}
+
+	for route, statuses := range c.routes {
+		if known[route] {
+			continue
+		}
+
+		rc := routeCoverage{Route: route, Statuses: statuses}
+		for _, n := range statuses {
+			rc.Calls += n
+		}
+		report.UnknownRoutes = append(report.UnknownRoutes, rc)
+	}
+	sort.Slice(report.UnknownRoutes, func(i, j int) bool {
+		return report.UnknownRoutes[i].Route < report.UnknownRoutes[j].Route
+	})
+
+	return report
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func (r *coverageReport) writeText(w io.Writer) {
+	fmt.Fprintf(w, "Gateway coverage: %d of %d routes called\n", len(r.Covered), len(r.Covered)+len(r.UntestedRoutes))
+
+	fmt.Fprintf(w, "\nCalled routes:\n")
+	for _, rc := range r.Covered {
+		var statuses []string
+		for _, class := range sortedKeys(rc.Statuses) {
+			statuses = append(statuses, fmt.Sprintf("%s=%d", class, rc.Statuses[class]))
+		}
+
+		fmt.Fprintf(w, "  %-55s %s", rc.Route, strings.Join(statuses, " "))
+		if len(rc.UntestedStatuses) > 0 {
+			fmt.Fprintf(w, "  (untested: %s)", strings.Join(rc.UntestedStatuses, ", "))
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(r.UntestedRoutes) > 0 {
+		fmt.Fprintf(w, "\nUntested routes:\n")
+		for _, route := range r.UntestedRoutes {
+			fmt.Fprintf(w, "  %s\n", route)
+		}
+	}
+
+	if len(r.UnknownRoutes) > 0 {
+		fmt.Fprintf(w, "\nCalled routes missing from the inventory:\n")
+		for _, rc := range r.UnknownRoutes {
+			fmt.Fprintf(w, "  %s (%d calls)\n", rc.Route, rc.Calls)
+		}
+	}
+
+	fmt.Fprintf(w, "\nRoutes by test:\n")
+	for _, test := range sortedKeys(r.Tests) {
+		fmt.Fprintf(w, "  %s\n", test)
+		for _, route := range sortedKeys(r.Tests[test]) {
+			fmt.Fprintf(w, "    %-53s %d\n", route, r.Tests[test][route])
+		}
+	}
+}
+
+// write saves the report as coverage.txt and coverage.json in dir.
+func (r *coverageReport) write(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(filepath.Join(dir, "coverage.txt"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r.writeText(f)
+
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, "coverage.json"), b, 0644)
+}
@@ -0,0 +1,234 @@
+package main_suite_test
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// table is a schema qualified platform table. The connection has no default
+// schema, so every query names one.
+type table struct {
+	schema string
+	name   string
+}
+
+func (t table) String() string {
+	return t.schema + "." + t.name
+}
+
+var (
+	organizationTable           = table{"organization", "organization"}
+	userTable                   = table{"organization", "user"}
+	attributeTable              = table{"organization", "attribute"}
+	learningGroupTable          = table{"organization", "learning_group"}
+	courseTable                 = table{"course", "course"}
+	learningItemTable           = table{"course", "learning_item"}
+	cardTable                   = table{"course", "card"}
+	learningPlanTable           = table{"learning_plan", "learning_plan"}
+	learningPlanCourseTable     = table{"learning_plan", "learning_plan_course"}
+	invitationTable             = table{"enrollment", "invitation"}
+	courseEnrollmentTable       = table{"enrollment", "course_enrollment"}
+	learningItemEnrollmentTable = table{"enrollment", "learning_item_enrollment"}
+	cardEnrollmentTable         = table{"enrollment", "card_enrollment"}
+)
+
+type foreignKey struct {
+	child  table
+	column string
+	parent table
+}
+
+func (fk foreignKey) String() string {
+	return fmt.Sprintf("%s.%s -> %s", fk.child, fk.column, fk.parent)
+}
+
+// platformForeignKeys lists the references between services that are not
+// enforced by MySQL, as each service owns its own schema.
+var platformForeignKeys = []foreignKey{
+	{userTable, "organization_id", organizationTable},
+	{attributeTable, "organization_id", organizationTable},
+	{learningGroupTable, "organization_id", organizationTable},
+	{courseTable, "organization_id", organizationTable},
+	{learningItemTable, "course_id", courseTable},
+	{cardTable, "learning_item_id", learningItemTable},
+	{learningPlanTable, "organization_id", organizationTable},
+	{learningPlanCourseTable, "learning_plan_id", learningPlanTable},
+	{learningPlanCourseTable, "course_id", courseTable},
+	{invitationTable, "course_id", courseTable},
+	{invitationTable, "invited_user_id", userTable},
+	{courseEnrollmentTable, "invitation_id", invitationTable},
+	{learningItemEnrollmentTable, "course_enrollment_id", courseEnrollmentTable},
+	{cardEnrollmentTable, "learning_item_enrollment_id", learningItemEnrollmentTable},
+}
+
+// dbRow is a row keyed by column name. Values are strings or nil, as the
+// connection does not parse times.
+type dbRow map[string]any
+
+func (r dbRow) str(column string) string {
+	v, _ := r[column].(string)
+	return v
+}
+
+func (r dbRow) isNull(column string) bool {
+	return r[column] == nil
+}
+
+func (r dbRow) int(column string) int64 {
+	v, _ := strconv.ParseInt(r.str(column), 10, 64)
+	return v
+}
+
+func (r dbRow) bool(column string) bool {
+	return r.int(column) != 0
+}
+
+func (r dbRow) time(column string) (time.Time, bool) {
+	if r.isNull(column) {
+		return time.Time{}, false
+	}
+
+	t, err := time.ParseInLocation("2006-01-02 15:04:05.999999", r.str(column), time.UTC)
+	return t, err == nil
+}
+
+type auditColumns struct {
+	createdAt time.Time
+	updatedAt time.Time
+	deletedAt *time.Time
+	createdBy string
+	updatedBy string
+}
+
+func (r dbRow) audit() auditColumns {
+	a := auditColumns{createdBy: r.str("created_by"), updatedBy: r.str("updated_by")}
+	a.createdAt, _ = r.time("created_at")
+	a.updatedAt, _ = r.time("updated_at")
+	if deletedAt, ok := r.time("deleted_at"); ok {
+		a.deletedAt = &deletedAt
+	}
+
+	return a
+}
+
+// dbQuery is a small typed query layer over the platform schemas.
+type dbQuery struct {
+	db *sql.DB
+}
+
+func newDBQuery(db *sql.DB) *dbQuery {
+	return &dbQuery{db: db}
+}
+
+func scanRows(rows *sql.Rows) ([]dbRow, error) {
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []dbRow
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		dest := make([]any, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+
+		if err = rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		row := dbRow{}
+		for i, c := range columns {
+			if values[i].Valid {
+				row[c] = values[i].String
+			} else {
+				row[c] = nil
+			}
+		}
+		result = append(result, row)
+	}
+
+	return result, rows.Err()
+}
+
+func (q *dbQuery) rows(t table, where string, args ...any) ([]dbRow, error) {
+	rows, err := q.db.Query(fmt.Sprintf("select * from %s where %s", t, where), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanRows(rows)
+}
+
+// row returns the row with the given id, or nil if there is none.
+func (q *dbQuery) row(t table, id string) (dbRow, error) {
+	rows, err := q.rows(t, "id = ?", id)
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+
+	return rows[0], nil
+}
+
+func (q *dbQuery) count(t table, where string, args ...any) (n int, err error) {
+	err = q.db.QueryRow(fmt.Sprintf("select count(*) from %s where %s", t, where), args...).Scan(&n)
+	return n, err
+}
+
+// orphans returns the ids of the rows referencing parentID through fk when
+// there is no such parent row. Scans are scoped to a parent, as other suites
+// hard delete their orgs while the run goes on.
+func (q *dbQuery) orphans(fk foreignKey, parentID string) ([]string, error) {
+	rows, err := q.db.Query(fmt.Sprintf(`select c.id from %s c left join %s p on p.id = c.%s
+		where c.%s = ? and p.id is null`, fk.child, fk.parent, fk.column, fk.column), parentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// waitForRow polls until a row with id shows up, for rows written
+// asynchronously by workers and event consumers.
+func (q *dbQuery) waitForRow(t table, id string, timeout time.Duration) (dbRow, error) {
+	return q.waitFor(t, timeout, func(rows []dbRow) bool { return len(rows) > 0 }, "id = ?", id)
+}
+
+// waitFor polls rows matching where until done accepts them or timeout passes.
+func (q *dbQuery) waitFor(t table, timeout time.Duration, done func([]dbRow) bool, where string, args ...any) (dbRow, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		rows, err := q.rows(t, where, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		if done(rows) {
+			if len(rows) == 0 {
+				return nil, nil
+			}
+			return rows[0], nil
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("no matching row in %s where %s %v after %s", t, where, args, timeout)
+		}
+
+		time.Sleep(time.Second)
+	}
+}
@@ -0,0 +1,111 @@
+package main_suite_test
+
+import (
+	"slices"
+	"time"
+)
+
+// assertNoOrphans checks that the rows referencing the row id of parent still
+// find it.
+func (s *harness) assertNoOrphans(parent table, id string) {
+	for _, fk := range platformForeignKeys {
+		if fk.parent != parent {
+			continue
+		}
+
+		orphans, err := s.dbq.orphans(fk, id)
+		s.Assert().Nil(err, fk.String())
+		s.Assert().Empty(orphans, "orphans in %s", fk)
+	}
+}
+
+func (s *ContentSuite) TestDBCourseRows() {
+	s.requireDB()
+
+	row, err := s.dbq.row(courseTable, s.course.ID)
+	s.Require().Nil(err)
+	s.Require().NotNil(row)
+	s.Assert().Equal(s.org.ID, row.str("organization_id"))
+
+	audit := row.audit()
+	s.Assert().Nil(audit.deletedAt)
+	s.Assert().False(audit.createdAt.IsZero())
+	s.Assert().False(audit.updatedAt.Before(audit.createdAt))
+	s.Assert().Equal(s.orgAdminInfo.ID, audit.createdBy)
+
+	for learningItemID, cards := range map[string][]*card{s.lesson.ID: s.lessonCards, s.quiz.ID: s.quizCards} {
+		li, err := s.dbq.row(learningItemTable, learningItemID)
+		s.Require().Nil(err)
+		s.Require().NotNil(li)
+		s.Assert().Equal(s.course.ID, li.str("course_id"))
+
+		n, err := s.dbq.count(cardTable, "learning_item_id = ? and deleted_at is null", learningItemID)
+		s.Require().Nil(err)
+		s.Assert().Equal(len(cards), n)
+	}
+}
+
+func (s *IdentitySuite) TestDBOrganizationScoping() {
+	s.requireDB()
+
+	for _, t := range []table{courseTable, learningPlanTable, learningGroupTable, attributeTable} {
+		n, err := s.dbq.count(t, "organization_id = ?", s.otherOrg.ID)
+		s.Require().Nil(err)
+		s.Assert().Zero(n, "%s rows in the other org", t)
+	}
+
+	n, err := s.dbq.count(userTable, "organization_id = ? and id = ?", s.org.ID, s.otherAdminInfo.ID)
+	s.Require().Nil(err)
+	s.Assert().Zero(n)
+}
+
+// TestDBDeletedOrg deletes an org holding users, attributes, groups and a
+// course, and checks none of its rows is left pointing at it.
+func (s *IdentitySuite) TestDBDeletedOrg() {
+	s.requireDB()
+
+	b := newWorldBuilder()
+	school := b.org("deleted")
+	school.admin("admin")
+	school.learner("learner").attribute("color", "Blue")
+	school.attribute("color", "Red", "Blue")
+	school.group("blues").filter("color", "EQ", "Blue")
+	school.course("geography").lesson("lesson")
+
+	o := s.buildWorld(b).org("deleted")
+	s.testOrgs = slices.DeleteFunc(s.testOrgs, func(t *organization) bool { return t.ID == o.ID })
+
+	// deleteOrg checks the org's rows for orphans once the org is gone.
+	s.deleteOrg(o)
+
+	row, err := s.dbq.row(organizationTable, o.ID)
+	s.Require().Nil(err)
+	s.Assert().Nil(row)
+}
+
+func (s *EnrollmentSuite) TestDBEnrollmentRows() {
+	s.requireDB()
+
+	info, _, clone := s.enrolledLearner("db_enrollment")
+
+	row, err := s.dbq.waitForRow(courseEnrollmentTable, clone.CourseEnrollmentID, 30*time.Second)
+	s.Require().Nil(err)
+	s.Assert().Equal(info.ID, row.str("user_id"))
+	s.Assert().Equal(s.course.ID, row.str("course_id"))
+	s.Assert().Equal(clone.InvitationID, row.str("invitation_id"))
+
+	n, err := s.dbq.count(learningItemEnrollmentTable, "course_enrollment_id = ?", clone.CourseEnrollmentID)
+	s.Require().Nil(err)
+	s.Assert().Equal(len(clone.LearningItemEnrollments), n)
+
+	for _, li := range clone.LearningItemEnrollments {
+		n, err := s.dbq.count(cardEnrollmentTable, "learning_item_enrollment_id = ?", li.LearningItemEnrollmentId)
+		s.Require().Nil(err)
+		s.Assert().Equal(len(li.CardEnrollments), n)
+	}
+
+	invitation, err := s.dbq.row(invitationTable, clone.InvitationID)
+	s.Require().Nil(err)
+	s.Require().NotNil(invitation)
+	s.Assert().Equal(info.ID, invitation.str("invited_user_id"))
+}
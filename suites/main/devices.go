@@ -0,0 +1,294 @@
+package main_suite_test
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// enrollmentTimeLayout is the timestamp format devices use when syncing.
+const enrollmentTimeLayout = "2006-01-02T15:04:05.999Z07:00"
+
+func formatEnrollmentTime(t time.Time) string {
+	return t.Format(enrollmentTimeLayout)
+}
+
+// onlineDeviceID is used for progress made in the web app, without a device.
+const onlineDeviceID = ""
+
+// virtualDevice holds a device-local copy of cloned enrollments, the way an
+// offline client keeps them between two syncs.
+type virtualDevice struct {
+	id          string
+	enrollments []*learningItemEnrollment
+	touched     map[string]bool
+}
+
+// copyEnrollments deep copies enrollments so devices never share pointers.
+func copyEnrollments(enrollments []*learningItemEnrollment) ([]*learningItemEnrollment, error) {
+	b, err := json.Marshal(enrollments)
+	if err != nil {
+		return nil, err
+	}
+
+	var copied []*learningItemEnrollment
+	if err = json.Unmarshal(b, &copied); err != nil {
+		return nil, err
+	}
+
+	return copied, nil
+}
+
+func newVirtualDevice(id string, clone *cloneEnrollmentResponse) (*virtualDevice, error) {
+	enrollments, err := copyEnrollments(clone.LearningItemEnrollments)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &virtualDevice{
+		id:          id,
+		enrollments: enrollments,
+		touched:     map[string]bool{},
+	}
+
+	for _, li := range d.enrollments {
+		li.DeviceId = id
+		for _, c := range li.CardEnrollments {
+			c.DeviceID = id
+			c.ServerEnrollment = id == onlineDeviceID
+		}
+	}
+
+	return d, nil
+}
+
+func (d *virtualDevice) learningItem(learningItemID string) *learningItemEnrollment {
+	for _, li := range d.enrollments {
+		if li.LearningItemId == learningItemID {
+			return li
+		}
+	}
+
+	return nil
+}
+
+func (d *virtualDevice) card(learningItemID, cardID string) *cardEnrollment {
+	li := d.learningItem(learningItemID)
+	if li == nil {
+		return nil
+	}
+
+	for _, c := range li.CardEnrollments {
+		if c.CardId == cardID {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// answer records an answer on the device as if the learner gave it at `at`.
+func (d *virtualDevice) answer(learningItemID, cardID string, answer []string, at time.Time) {
+	li := d.learningItem(learningItemID)
+	c := d.card(learningItemID, cardID)
+	if c == nil {
+		return
+	}
+
+	ts := formatEnrollmentTime(at)
+	if c.StartedAt == "" {
+		c.StartedAt = ts
+	}
+	c.Answer = answer
+	c.Progress = 1
+	c.UpdatedAt = ts
+	c.CompletedAt = ts
+
+	if li.StartedAt == "" {
+		li.StartedAt = ts
+	}
+	if updatedAt, err := time.Parse(enrollmentTimeLayout, li.UpdatedAt); err != nil || updatedAt.Before(at) {
+		li.UpdatedAt = ts
+	}
+	d.touched[li.LearningItemEnrollmentId] = true
+}
+
+// written returns a copy of a learning item holding only the cards answered
+// on the device, the others being left as they were on the server.
+func (d *virtualDevice) written(learningItemID string) (*learningItemEnrollment, error) {
+	copied, err := copyEnrollments([]*learningItemEnrollment{d.learningItem(learningItemID)})
+	if err != nil {
+		return nil, err
+	}
+
+	li := copied[0]
+	var cards []*cardEnrollment
+	for _, c := range li.CardEnrollments {
+		if c.UpdatedAt != "" {
+			cards = append(cards, c)
+		}
+	}
+	li.CardEnrollments = cards
+
+	return li, nil
+}
+
+// syncRequest only carries the learning items touched on the device.
+func (d *virtualDevice) syncRequest() syncEnrollmentRequest {
+	var req syncEnrollmentRequest
+	for _, li := range d.enrollments {
+		if d.touched[li.LearningItemEnrollmentId] {
+			req.LearningItemEnrollments = append(req.LearningItemEnrollments, li)
+		}
+	}
+
+	return req
+}
+
+// snapshot deep copies the pending sync request, the way a device persists
+// it before sending so it can retry it later.
+func (d *virtualDevice) snapshot() (syncEnrollmentRequest, error) {
+	enrollments, err := copyEnrollments(d.syncRequest().LearningItemEnrollments)
+	if err != nil {
+		return syncEnrollmentRequest{}, err
+	}
+
+	return syncEnrollmentRequest{LearningItemEnrollments: enrollments}, nil
+}
+
+// deviceAnswer is one answer given on a virtual device, offset from the
+// simulator clock so interleavings are reproducible.
+type deviceAnswer struct {
+	deviceID       string
+	learningItemID string
+	cardID         string
+	answer         []string
+	after          time.Duration
+}
+
+// syncSimulator drives several virtual devices holding clones of the same
+// enrollment and computes the state the server should converge to, by the
+// fake platform's rules: last writer wins per card, and the first device to
+// sync a learning item claims its enrollment. The real platform documents
+// neither rule, so the tests built on it run against the fake only.
+type syncSimulator struct {
+	clone   *cloneEnrollmentResponse
+	clock   time.Time
+	devices map[string]*virtualDevice
+	answers []deviceAnswer
+	// claims holds, by learning item, the first device that synced it.
+	claims map[string]string
+}
+
+func newSyncSimulator(clone *cloneEnrollmentResponse, deviceIDs ...string) (*syncSimulator, error) {
+	sim := &syncSimulator{
+		clone:   clone,
+		clock:   time.Now().Add(-time.Hour).Truncate(time.Millisecond),
+		devices: map[string]*virtualDevice{},
+		claims:  map[string]string{},
+	}
+
+	for _, id := range deviceIDs {
+		d, err := newVirtualDevice(id, clone)
+		if err != nil {
+			return nil, err
+		}
+		sim.devices[id] = d
+	}
+
+	return sim, nil
+}
+
+func (sim *syncSimulator) apply(answers ...deviceAnswer) {
+	for _, a := range answers {
+		sim.devices[a.deviceID].answer(a.learningItemID, a.cardID, a.answer, sim.clock.Add(a.after))
+		sim.answers = append(sim.answers, a)
+	}
+}
+
+// synced records that a device synced its pending learning items, claiming
+// those no other device synced before.
+func (sim *syncSimulator) synced(deviceID string) {
+	for _, li := range sim.devices[deviceID].syncRequest().LearningItemEnrollments {
+		if _, ok := sim.claims[li.LearningItemId]; !ok {
+			sim.claims[li.LearningItemId] = deviceID
+		}
+	}
+}
+
+// claimant returns the device expected to own the enrollment of a learning
+// item: the first to sync it, or the first answering on it when syncs were
+// not recorded.
+func (sim *syncSimulator) claimant(learningItemID string) (string, bool) {
+	if deviceID, ok := sim.claims[learningItemID]; ok {
+		return deviceID, true
+	}
+
+	for _, a := range sim.answers {
+		if a.learningItemID == learningItemID {
+			return a.deviceID, true
+		}
+	}
+
+	return "", false
+}
+
+// lastWriters returns, per card, the answer with the latest UpdatedAt.
+func (sim *syncSimulator) lastWriters() map[string]deviceAnswer {
+	answers := append([]deviceAnswer(nil), sim.answers...)
+	sort.SliceStable(answers, func(i, j int) bool {
+		return answers[i].after < answers[j].after
+	})
+
+	last := map[string]deviceAnswer{}
+	for _, a := range answers {
+		last[a.cardID] = a
+	}
+
+	return last
+}
+
+// expected returns the enrollments the server should converge to: the cloned
+// enrollment with every answered card taken from its last writer, owned by
+// the device that claimed it.
+func (sim *syncSimulator) expected() ([]*learningItemEnrollment, error) {
+	enrollments, err := copyEnrollments(sim.clone.LearningItemEnrollments)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &virtualDevice{enrollments: enrollments}
+	for _, li := range state.enrollments {
+		if deviceID, ok := sim.claimant(li.LearningItemId); ok {
+			li.DeviceId = deviceID
+		}
+	}
+	for cardID, a := range sim.lastWriters() {
+		li := state.learningItem(a.learningItemID)
+		for i, c := range li.CardEnrollments {
+			if c.CardId == cardID {
+				written := *sim.devices[a.deviceID].card(a.learningItemID, cardID)
+				li.CardEnrollments[i] = &written
+			}
+		}
+	}
+
+	return state.enrollments, nil
+}
+
+// permutations returns every order in which ids can be synced.
+func permutations(ids []string) [][]string {
+	if len(ids) <= 1 {
+		return [][]string{append([]string(nil), ids...)}
+	}
+
+	var perms [][]string
+	for i, id := range ids {
+		rest := append(append([]string(nil), ids[:i]...), ids[i+1:]...)
+		for _, p := range permutations(rest) {
+			perms = append(perms, append([]string{id}, p...))
+		}
+	}
+
+	return perms
+}
@@ -0,0 +1,111 @@
+package main_suite_test
+
+import (
+	"time"
+)
+
+func learningItemEnrollmentIDs(clone *cloneEnrollmentResponse) []string {
+	ids := make([]string, len(clone.LearningItemEnrollments))
+	for i, li := range clone.LearningItemEnrollments {
+		ids[i] = li.LearningItemEnrollmentId
+	}
+
+	return ids
+}
+
+func (s *courseSuite) duplicateEnrollments(clone *cloneEnrollmentResponse) []*learningItemEnrollment {
+	duplicates, err := s.apiClient.getDuplicateEnrollments(getDuplicateEnrollmentsRequest{
+		LearningItemEnrollmentIDs: learningItemEnrollmentIDs(clone),
+	}, s.orgAdmin)
+	s.Require().Nil(err)
+
+	return duplicates
+}
+
+func (s *EnrollmentSuite) TestDuplicateEnrollmentsNoneBeforeSync() {
+	s.requireFakeRules()
+
+	_, _, clone := s.enrolledLearner("duplicates_none")
+
+	s.Assert().Empty(s.duplicateEnrollments(clone))
+
+	duplicates, err := s.apiClient.getDuplicateEnrollments(getDuplicateEnrollmentsRequest{
+		LearningItemEnrollmentIDs: []string{"not-found"},
+	}, s.orgAdmin)
+	s.Require().Nil(err)
+	s.Assert().Empty(duplicates)
+}
+
+func (s *EnrollmentSuite) TestDuplicateEnrollmentsTwoDevices() {
+	s.requireFakeRules()
+
+	_, _, clone := s.enrolledLearner("duplicates_two_devices")
+
+	q := s.quiz.ID
+	sim := s.syncSimulator(clone, deviceAID, deviceBID)
+	sim.apply(
+		deviceAnswer{deviceAID, q, s.quizCards[1].ID, []string{"Europe"}, time.Minute},
+		deviceAnswer{deviceBID, q, s.quizCards[3].ID, []string{"True"}, 2 * time.Minute},
+	)
+	s.syncDevices(sim, deviceAID, deviceBID)
+
+	duplicates := s.duplicateEnrollments(clone)
+	s.Require().Equal(1, len(duplicates))
+	s.Assert().Equal(deviceBID, duplicates[0].DeviceId)
+	s.Assert().Equal(s.quiz.ID, duplicates[0].LearningItemId)
+
+	// The duplicate keeps what was answered on its own device.
+	dup := &virtualDevice{enrollments: duplicates}
+	card := dup.card(q, s.quizCards[3].ID)
+	s.Require().NotNil(card)
+	s.Assert().Equal([]string{"True"}, card.Answer)
+}
+
+func (s *EnrollmentSuite) TestDuplicateEnrollmentsCloneTwice() {
+	s.requireFakeRules()
+
+	_, _, clone := s.enrolledLearner("duplicates_clone_twice")
+
+	again, err := s.apiClient.cloneEnrollment(cloneEnrollmentRequest{InvitationID: clone.InvitationID}, s.orgAdmin)
+	s.Require().Nil(err)
+	s.Require().Equal(clone.CourseEnrollmentID, again.CourseEnrollmentID)
+	s.Require().ElementsMatch(learningItemEnrollmentIDs(clone), learningItemEnrollmentIDs(again))
+
+	// Both clones land on the same device: that is an override, not a duplicate.
+	q := s.quiz.ID
+	first := s.syncSimulator(clone, deviceAID)
+	first.apply(deviceAnswer{deviceAID, q, s.quizCards[1].ID, []string{"Africa"}, time.Minute})
+	s.syncDevices(first, deviceAID)
+
+	second := s.syncSimulator(again, deviceAID)
+	second.apply(deviceAnswer{deviceAID, q, s.quizCards[1].ID, []string{"Europe"}, 2 * time.Minute})
+	s.syncDevices(second, deviceAID)
+
+	s.Assert().Empty(s.duplicateEnrollments(clone))
+	s.assertMergedEnrollment(clone, second)
+
+	// The same clone synced from a second device is a duplicate.
+	third := s.syncSimulator(again, deviceBID)
+	third.apply(deviceAnswer{deviceBID, q, s.quizCards[1].ID, []string{"Africa"}, 3 * time.Minute})
+	s.syncDevices(third, deviceBID)
+
+	duplicates := s.duplicateEnrollments(clone)
+	s.Require().Equal(1, len(duplicates))
+	s.Assert().Equal(deviceBID, duplicates[0].DeviceId)
+}
+
+func (s *EnrollmentSuite) TestDuplicateEnrollmentsOnlineAndOffline() {
+	s.requireFakeRules()
+
+	_, _, clone := s.enrolledLearner("duplicates_online_offline")
+
+	q := s.quiz.ID
+	sim := s.syncSimulator(clone, onlineDeviceID, deviceAID)
+	sim.apply(
+		deviceAnswer{onlineDeviceID, q, s.quizCards[1].ID, []string{"Europe"}, time.Minute},
+		deviceAnswer{deviceAID, q, s.quizCards[1].ID, []string{"Africa"}, 2 * time.Minute},
+		deviceAnswer{deviceAID, q, s.quizCards[2].ID, []string{"France"}, 3 * time.Minute},
+	)
+	s.syncDevices(sim, onlineDeviceID, deviceAID)
+	s.assertDuplicates(clone, []string{onlineDeviceID, deviceAID})
+}
@@ -0,0 +1,251 @@
+package main_suite_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// EnrollmentSuite covers enrolling in the course and syncing the enrollments
+// of offline devices.
+type EnrollmentSuite struct {
+	courseSuite
+}
+
+func TestEnrollmentSuite(t *testing.T) {
+	runSuite[EnrollmentSuite](t)
+}
+
+// waitForEnrollmentJob polls an enrollment job until it reaches a terminal
+// status, timing it from the first poll.
+func (s *courseSuite) waitForEnrollmentJob(jobID string) *invitationEnrollResponse {
+	var resp *invitationEnrollResponse
+	var err error
+	start := time.Now()
+	for i := 0; i < 5; i += 1 {
+		resp, err = s.apiClient.enrollmentJob(jobID, s.orgAdmin)
+		s.Require().Nil(err)
+
+		if resp.Status.terminal() {
+			s.recordJob("enrollment", jobID, start)
+			return resp
+		}
+
+		time.Sleep(time.Second * 5)
+	}
+
+	s.Require().FailNow("enrollment job did not finish", "job %s is %s", jobID, resp.Status)
+	return resp
+}
+
+// enroll enrolls the invitation and waits for the enrollment job to complete.
+func (s *courseSuite) enroll(invitationID string) *invitationEnrollResponse {
+	resp, err := s.apiClient.invitationEnroll(invitationEnrollRequest{InvitationID: invitationID}, s.orgAdmin)
+	s.Require().Nil(err)
+
+	resp = s.waitForEnrollmentJob(resp.ID)
+	s.Require().Nil(resp.err())
+	s.Require().Equal(enrollmentJobCompleted, resp.Status)
+
+	return resp
+}
+
+// enrollFails enrolls the invitation and requires the job to end with status.
+func (s *courseSuite) enrollFails(invitationID string, status enrollmentJobStatus) *enrollmentJobError {
+	resp, err := s.apiClient.invitationEnroll(invitationEnrollRequest{InvitationID: invitationID}, s.orgAdmin)
+	s.Require().Nil(err)
+
+	resp = s.waitForEnrollmentJob(resp.ID)
+
+	var jobErr *enrollmentJobError
+	s.Require().ErrorAs(resp.err(), &jobErr)
+	s.Assert().Equal(status, jobErr.status)
+	s.Assert().Equal(resp.ID, jobErr.jobID)
+
+	return jobErr
+}
+
+// enrolledLearner creates a learner with a completed enrollment in the
+// suite's course and returns the enrollment as cloned for a device.
+func (s *courseSuite) enrolledLearner(name string) (*user, userCredentials, *cloneEnrollmentResponse) {
+	info, credentials := s.newLearner(name)
+	invitation := s.waitForInvitation(s.course.ID, info.ID)
+	s.enroll(invitation.ID)
+
+	clone, err := s.apiClient.cloneEnrollment(cloneEnrollmentRequest{InvitationID: invitation.ID}, s.orgAdmin)
+	s.Require().Nil(err)
+	s.Require().Equal(info.ID, clone.UserID)
+
+	return info, credentials, clone
+}
+
+// assertSameTime compares two sync timestamps of field to the millisecond.
+func (s *courseSuite) assertSameTime(expected, actual string, field string) {
+	if expected == "" || actual == "" {
+		s.Assert().Equal(expected, actual, field)
+		return
+	}
+
+	e, err := time.Parse(enrollmentTimeLayout, expected)
+	s.Require().Nil(err, field)
+	a, err := time.Parse(enrollmentTimeLayout, actual)
+	s.Require().Nil(err, field)
+
+	s.Assert().True(e.Truncate(time.Millisecond).Equal(a.Truncate(time.Millisecond)), "%s: expected %s, got %s", field, expected, actual)
+}
+
+// storedLearningItem returns the stored enrollment li was synced to, looked
+// up by id so that duplicates of the same learning item are told apart.
+func storedLearningItem(stored []*learningItemEnrollment, li *learningItemEnrollment) *learningItemEnrollment {
+	for _, sli := range stored {
+		if sli.LearningItemEnrollmentId == li.LearningItemEnrollmentId {
+			return sli
+		}
+	}
+
+	return nil
+}
+
+// storedCard returns the card enrollment of li for cardID.
+func storedCard(li *learningItemEnrollment, cardID string) *cardEnrollment {
+	for _, c := range li.CardEnrollments {
+		if c.CardId == cardID {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// assertStoredEnrollment reads back a course enrollment and checks every card
+// enrollment of expected was persisted as synced, its learning item owned by
+// the expected device. Totals and scores are computed by the platform and
+// left to the scoring tests. Replays have nothing to read back from and only
+// log that the check was skipped.
+func (s *courseSuite) assertStoredEnrollment(courseEnrollmentID string, expected []*learningItemEnrollment) *courseEnrollment {
+	if s.enrollments == nil {
+		s.T().Log("no database or fake platform to read the stored enrollment from")
+		return nil
+	}
+
+	stored, err := s.enrollments.courseEnrollment(courseEnrollmentID)
+	s.Require().Nil(err)
+	s.Require().Equal(courseEnrollmentID, stored.CourseEnrollmentID)
+
+	for _, li := range expected {
+		sli := storedLearningItem(stored.LearningItemEnrollments, li)
+		if !s.Assert().NotNil(sli, "learning item enrollment %s", li.LearningItemEnrollmentId) {
+			continue
+		}
+
+		s.Assert().Equal(li.DeviceId, sli.DeviceId, "device of learning item %s", li.LearningItemId)
+
+		for _, c := range li.CardEnrollments {
+			sc := storedCard(sli, c.CardId)
+			if !s.Assert().NotNil(sc, "card %s", c.CardId) {
+				continue
+			}
+
+			if len(c.Answer) > 0 || len(sc.Answer) > 0 {
+				s.Assert().Equal(c.Answer, sc.Answer, "answer of card %s", c.CardId)
+			}
+			s.Assert().Equal(c.Confidence, sc.Confidence, "confidence of card %s", c.CardId)
+			s.Assert().Equal(c.Progress, sc.Progress, "progress of card %s", c.CardId)
+			s.Assert().Equal(c.DeviceID, sc.DeviceID, "device of card %s", c.CardId)
+			s.assertSameTime(c.StartedAt, sc.StartedAt, "startedAt of card "+c.CardId)
+			s.assertSameTime(c.UpdatedAt, sc.UpdatedAt, "updatedAt of card "+c.CardId)
+			s.assertSameTime(c.CompletedAt, sc.CompletedAt, "completedAt of card "+c.CardId)
+		}
+	}
+
+	return stored
+}
+
+func (s *EnrollmentSuite) TestEnrollInvitationTwice() {
+	info, _ := s.newLearner("enroll_twice")
+	invitation := s.waitForInvitation(s.course.ID, info.ID)
+	s.enroll(invitation.ID)
+
+	clone, err := s.apiClient.cloneEnrollment(cloneEnrollmentRequest{InvitationID: invitation.ID}, s.orgAdmin)
+	s.Require().Nil(err)
+
+	s.enrollFails(invitation.ID, enrollmentJobAlreadyEnrolled)
+
+	// The first enrollment is left untouched.
+	again, err := s.apiClient.cloneEnrollment(cloneEnrollmentRequest{InvitationID: invitation.ID}, s.orgAdmin)
+	s.Require().Nil(err)
+	s.Assert().Equal(clone.CourseEnrollmentID, again.CourseEnrollmentID)
+	s.Assert().ElementsMatch(learningItemEnrollmentIDs(clone), learningItemEnrollmentIDs(again))
+}
+
+// TestEnrollRevokedInvitation revokes the invitation in the fake: the gateway
+// has no endpoint doing so.
+func (s *EnrollmentSuite) TestEnrollRevokedInvitation() {
+	s.requireFakeRules()
+
+	info, _ := s.newLearner("enroll_revoked")
+	invitation := s.waitForInvitation(s.course.ID, info.ID)
+
+	err := fake.updateInvitation(invitation.ID, func(inv *fakeInvitation) { inv.Status = "REVOKED" })
+	s.Require().Nil(err)
+
+	s.enrollFails(invitation.ID, enrollmentJobRevoked)
+
+	_, err = s.apiClient.cloneEnrollment(cloneEnrollmentRequest{InvitationID: invitation.ID}, s.orgAdmin)
+	s.httpCode(err, http.StatusNotFound)
+}
+
+// TestEnrollExpiredInvitation expires the invitation in the fake: the gateway
+// has no endpoint doing so.
+func (s *EnrollmentSuite) TestEnrollExpiredInvitation() {
+	s.requireFakeRules()
+
+	info, _ := s.newLearner("enroll_expired")
+	invitation := s.waitForInvitation(s.course.ID, info.ID)
+
+	err := fake.updateInvitation(invitation.ID, func(inv *fakeInvitation) {
+		inv.ExpiresAt = time.Now().Add(-time.Hour).Format(time.RFC3339)
+	})
+	s.Require().Nil(err)
+
+	s.enrollFails(invitation.ID, enrollmentJobExpired)
+}
+
+func (s *EnrollmentSuite) TestEnrollArchivedCourse() {
+	course := s.setupExtraCourse("Archived Geography")
+	invitation := s.waitForInvitation(course.ID, s.learnerInfo.ID)
+
+	_, err := s.apiClient.archiveCourse(course.ID, s.orgAdmin)
+	s.Require().Nil(err)
+
+	s.enrollFails(invitation.ID, enrollmentJobCourseArchived)
+}
+
+func (s *EnrollmentSuite) TestEnrollAsOtherOrgAdmin() {
+	info, _ := s.newLearner("enroll_other_org")
+	invitation := s.waitForInvitation(s.course.ID, info.ID)
+
+	_, err := s.apiClient.invitationEnroll(invitationEnrollRequest{InvitationID: invitation.ID}, s.otherAdmin)
+	s.httpCode(err, http.StatusNotFound, "could not find invitation")
+
+	// The invitation can still be used by its own org.
+	s.enroll(invitation.ID)
+}
+
+func (s *EnrollmentSuite) TestEnrollmentJobUnknownID() {
+	_, err := s.apiClient.enrollmentJob("not-found", s.orgAdmin)
+	s.httpCode(err, http.StatusNotFound, "could not find enrollment job")
+
+	info, _ := s.newLearner("enroll_job_scope")
+	invitation := s.waitForInvitation(s.course.ID, info.ID)
+
+	resp, err := s.apiClient.invitationEnroll(invitationEnrollRequest{InvitationID: invitation.ID}, s.orgAdmin)
+	s.Require().Nil(err)
+
+	// Jobs are scoped to their org like everything else.
+	_, err = s.apiClient.enrollmentJob(resp.ID, s.otherAdmin)
+	s.httpCode(err, http.StatusNotFound, "could not find enrollment job")
+
+	resp = s.waitForEnrollmentJob(resp.ID)
+	s.Assert().Equal(enrollmentJobCompleted, resp.Status)
+}
@@ -0,0 +1,142 @@
+package main_suite_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// courseEnrollment is what the platform stored for a course enrollment. The
+// gateway has no endpoint reading it back, so it is read from the database.
+type courseEnrollment struct {
+	CourseEnrollmentID      string
+	CourseID                string
+	UserID                  string
+	InvitationID            string
+	Progress                int32
+	TotalPoints             int32
+	StartedAt               string
+	UpdatedAt               string
+	CompletedAt             string
+	LearningItemEnrollments []*learningItemEnrollment
+}
+
+// enrollmentReader reads back what the platform persisted for a course
+// enrollment, including its learning item and card enrollments.
+type enrollmentReader interface {
+	courseEnrollment(courseEnrollmentID string) (*courseEnrollment, error)
+}
+
+// newEnrollmentReader reads the database, or the state of fake when running
+// against it. It returns nil when there is neither, on replays.
+func newEnrollmentReader(db *sql.DB, fake *fakePlatform) enrollmentReader {
+	switch {
+	case db != nil:
+		return &dbEnrollmentReader{db: db}
+	case fake != nil:
+		return fake
+	}
+
+	return nil
+}
+
+// dbEnrollmentReader reads the enrollment schema directly.
+type dbEnrollmentReader struct {
+	db *sql.DB
+}
+
+// dbTime converts a MySQL DATETIME(3) column, stored in UTC, to the
+// timestamp format used by the sync endpoints.
+func dbTime(v sql.NullString) string {
+	if !v.Valid {
+		return ""
+	}
+
+	t, err := time.ParseInLocation("2006-01-02 15:04:05.999999", v.String, time.UTC)
+	if err != nil {
+		return v.String
+	}
+
+	return formatEnrollmentTime(t)
+}
+
+func (r *dbEnrollmentReader) courseEnrollment(courseEnrollmentID string) (*courseEnrollment, error) {
+	ce := &courseEnrollment{}
+	var startedAt, updatedAt, completedAt sql.NullString
+	err := r.db.QueryRow(`select id, course_id, user_id, invitation_id, progress, total_points, started_at, updated_at, completed_at
+		from enrollment.course_enrollment where id = ?`, courseEnrollmentID).
+		Scan(&ce.CourseEnrollmentID, &ce.CourseID, &ce.UserID, &ce.InvitationID, &ce.Progress, &ce.TotalPoints, &startedAt, &updatedAt, &completedAt)
+	if err != nil {
+		return nil, err
+	}
+	ce.StartedAt, ce.UpdatedAt, ce.CompletedAt = dbTime(startedAt), dbTime(updatedAt), dbTime(completedAt)
+
+	if ce.LearningItemEnrollments, err = r.learningItemEnrollments(courseEnrollmentID); err != nil {
+		return nil, err
+	}
+
+	for _, li := range ce.LearningItemEnrollments {
+		if li.CardEnrollments, err = r.cardEnrollments(li.LearningItemEnrollmentId); err != nil {
+			return nil, err
+		}
+	}
+
+	return ce, nil
+}
+
+func (r *dbEnrollmentReader) learningItemEnrollments(courseEnrollmentID string) ([]*learningItemEnrollment, error) {
+	rows, err := r.db.Query(`select id, course_enrollment_id, learning_item_id, device_id, progress, total_points, started_at, updated_at, completed_at
+		from enrollment.learning_item_enrollment where course_enrollment_id = ?`, courseEnrollmentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lis []*learningItemEnrollment
+	for rows.Next() {
+		li := &learningItemEnrollment{}
+		var deviceID, startedAt, updatedAt, completedAt sql.NullString
+		if err = rows.Scan(&li.LearningItemEnrollmentId, &li.CourseEnrollmentId, &li.LearningItemId, &deviceID, &li.Progress, &li.TotalPoints, &startedAt, &updatedAt, &completedAt); err != nil {
+			return nil, err
+		}
+
+		li.DeviceId = deviceID.String
+		li.StartedAt, li.UpdatedAt, li.CompletedAt = dbTime(startedAt), dbTime(updatedAt), dbTime(completedAt)
+		lis = append(lis, li)
+	}
+
+	return lis, rows.Err()
+}
+
+func (r *dbEnrollmentReader) cardEnrollments(learningItemEnrollmentID string) ([]*cardEnrollment, error) {
+	rows, err := r.db.Query(`select id, learning_item_enrollment_id, card_id, device_id, score, elapsed_sec, approved, answer, confidence, progress, total_points,
+		created_at, updated_at, started_at, completed_at
+		from enrollment.card_enrollment where learning_item_enrollment_id = ?`, learningItemEnrollmentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cards []*cardEnrollment
+	for rows.Next() {
+		c := &cardEnrollment{}
+		var deviceID, answer, createdAt, updatedAt, startedAt, completedAt sql.NullString
+		if err = rows.Scan(&c.CardEnrollmentId, &c.LearningItemEnrollmentId, &c.CardId, &deviceID, &c.Score, &c.ElapsedSec, &c.Approved, &answer,
+			&c.Confidence, &c.Progress, &c.TotalPoints, &createdAt, &updatedAt, &startedAt, &completedAt); err != nil {
+			return nil, err
+		}
+
+		if answer.Valid {
+			if err = json.Unmarshal([]byte(answer.String), &c.Answer); err != nil {
+				return nil, err
+			}
+		}
+
+		c.DeviceID = deviceID.String
+		c.CreatedAt, c.UpdatedAt = dbTime(createdAt), dbTime(updatedAt)
+		c.StartedAt, c.CompletedAt = dbTime(startedAt), dbTime(completedAt)
+		cards = append(cards, c)
+	}
+
+	return cards, rows.Err()
+}
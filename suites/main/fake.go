@@ -0,0 +1,428 @@
+package main_suite_test
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	fakeSuperAdminOrgID = "super-admin"
+	fakeAdminRealm      = "master"
+)
+
+// fakePlatform is an in-process stand-in for Keycloak, the contexts service
+// and the API gateway. It implements the endpoints apiClient uses with the
+// behaviour the suite expects, so the suite runs without the real stack when
+// FAKE_PLATFORM=true. It keeps everything in memory behind a single lock.
+type fakePlatform struct {
+	*httptest.Server
+
+	mu  sync.Mutex
+	mux *http.ServeMux
+
+	accessTokens  map[string]*fakeUser
+	contextTokens map[string]*fakeSession
+
+	orgs       map[string]*fakeOrg
+	users      map[string]*fakeUser
+	attributes map[string]*fakeAttribute
+	groups     map[string]*fakeGroup
+
+	courses       map[string]*fakeCourse
+	learningItems map[string]*fakeLearningItem
+	cards         map[string]*fakeCard
+	plans         map[string]*fakePlan
+	bundles       map[string]*fakeBundle
+
+	invitations     map[string]*fakeInvitation
+	jobs            map[string]*fakeJob
+	enrollments     map[string]*fakeEnrollment
+	liEnrollments   map[string]*fakeLIEnrollment
+	cardEnrollments map[string]*fakeLIEnrollment
+}
+
+type fakeOrg struct {
+	organization
+}
+
+type fakeUser struct {
+	user
+	orgID      string
+	superAdmin bool
+	realmAdmin bool
+	attributes map[string]string
+}
+
+func (u *fakeUser) isAdmin() bool {
+	return u.superAdmin || slices.Contains(u.Roles, "ROLE_ADMIN")
+}
+
+type fakeSession struct {
+	user  *fakeUser
+	orgID string
+}
+
+type fakeAttribute struct {
+	orgAttribute
+	orgID string
+}
+
+type fakeGroup struct {
+	learningGroup
+	orgID   string
+	filters []*attributeFilter
+}
+
+func (g *fakeGroup) matches(u *fakeUser) bool {
+	if u.orgID != g.orgID {
+		return false
+	}
+
+	for _, f := range g.filters {
+		if f.FilterOperator != "EQ" || u.attributes[f.AttributeID] != f.Value {
+			return false
+		}
+	}
+
+	return true
+}
+
+type fakeMessage struct {
+	Message string `json:"message"`
+}
+
+func fakeErr(code int, msg string) (int, any) {
+	return code, fakeMessage{msg}
+}
+
+var errFakeUnauthorized = errors.New("unauthorized")
+
+func startFakePlatform() *fakePlatform {
+	f := &fakePlatform{
+		mux:             http.NewServeMux(),
+		accessTokens:    map[string]*fakeUser{},
+		contextTokens:   map[string]*fakeSession{},
+		orgs:            map[string]*fakeOrg{},
+		users:           map[string]*fakeUser{},
+		attributes:      map[string]*fakeAttribute{},
+		groups:          map[string]*fakeGroup{},
+		courses:         map[string]*fakeCourse{},
+		learningItems:   map[string]*fakeLearningItem{},
+		cards:           map[string]*fakeCard{},
+		plans:           map[string]*fakePlan{},
+		bundles:         map[string]*fakeBundle{},
+		invitations:     map[string]*fakeInvitation{},
+		jobs:            map[string]*fakeJob{},
+		enrollments:     map[string]*fakeEnrollment{},
+		liEnrollments:   map[string]*fakeLIEnrollment{},
+		cardEnrollments: map[string]*fakeLIEnrollment{},
+	}
+
+	superAdmin := &fakeUser{
+		user:       user{ID: uuid.NewString(), Email: config.superAdminEmail},
+		orgID:      config.superAdminOrgID,
+		superAdmin: true,
+	}
+	f.users[superAdmin.ID] = superAdmin
+
+	f.mux.HandleFunc("POST /realms/{realm}/protocol/openid-connect/token", f.token)
+	f.mux.HandleFunc("DELETE /admin/realms/{realm}", f.deleteRealm)
+
+	f.route("POST /v1/contexts", f.createContext, false)
+	f.route("GET /v1/attributes", f.listAttributes, true)
+	f.route("POST /v1/attributes", f.createAttribute, true)
+	f.route("POST /v1/organizations", f.createOrganization, true)
+	f.route("POST /v1/users", f.createUser, true)
+	f.route("POST /v1/user_attributes", f.assignUserAttribute, true)
+	f.route("POST /v1/learning_groups", f.createLearningGroup, true)
+	f.routeContent()
+	f.routeEnrollment()
+
+	f.Server = httptest.NewServer(f.mux)
+	return f
+}
+
+// route registers an authenticated handler. Gateway handlers need both the
+// access and the context token, the contexts service only the former.
+func (f *fakePlatform) route(pattern string, h func(sess *fakeSession, r *http.Request) (int, any), needsContext bool) {
+	f.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		sess, err := f.session(r, needsContext)
+		if err != nil {
+			writeFake(w, http.StatusUnauthorized, fakeMessage{err.Error()})
+			return
+		}
+
+		code, v := h(sess, r)
+		writeFake(w, code, v)
+	})
+}
+
+func writeFake(w http.ResponseWriter, code int, v any) {
+	if v == nil {
+		w.WriteHeader(code)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/ld+json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func decodeFake(r *http.Request, v any) error {
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
+func (f *fakePlatform) session(r *http.Request, needsContext bool) (*fakeSession, error) {
+	u, ok := f.accessTokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+	if !ok {
+		return nil, errFakeUnauthorized
+	}
+
+	if !needsContext {
+		return &fakeSession{user: u, orgID: u.orgID}, nil
+	}
+
+	sess, ok := f.contextTokens[r.Header.Get("x-context-token")]
+	if !ok || sess.user != u {
+		return nil, errFakeUnauthorized
+	}
+
+	return sess, nil
+}
+
+func (f *fakePlatform) token(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	realm := r.PathValue("realm")
+	username, password := r.FormValue("username"), r.FormValue("password")
+
+	var u *fakeUser
+	switch {
+	case realm == fakeAdminRealm && username == "admin" && password == "admin":
+		u = &fakeUser{realmAdmin: true}
+	case realm == config.superAdminOrgID && username == config.superAdminEmail && password == config.superAdminPassword:
+		for _, candidate := range f.users {
+			if candidate.superAdmin {
+				u = candidate
+			}
+		}
+	case password == "password":
+		for _, candidate := range f.users {
+			if candidate.orgID == realm && candidate.Email == username {
+				u = candidate
+			}
+		}
+	}
+
+	if u == nil {
+		writeFake(w, http.StatusUnauthorized, fakeMessage{"invalid user credentials"})
+		return
+	}
+
+	token := uuid.NewString()
+	f.accessTokens[token] = u
+	writeFake(w, http.StatusOK, map[string]string{"access_token": token})
+}
+
+func (f *fakePlatform) deleteRealm(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if u, ok := f.accessTokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]; !ok || !u.realmAdmin {
+		writeFake(w, http.StatusUnauthorized, fakeMessage{"unauthorized"})
+		return
+	}
+
+	if _, ok := f.orgs[r.PathValue("realm")]; !ok {
+		writeFake(w, http.StatusNotFound, fakeMessage{"realm not found"})
+		return
+	}
+
+	f.deleteOrg(r.PathValue("realm"))
+	writeFake(w, http.StatusNoContent, nil)
+}
+
+// deleteOrg removes the org with everything it holds, and revokes the tokens
+// of its users and the contexts of the org, so that the credentials of a
+// deleted realm stop working.
+func (f *fakePlatform) deleteOrg(orgID string) {
+	delete(f.orgs, orgID)
+	maps.DeleteFunc(f.users, func(_ string, u *fakeUser) bool { return u.orgID == orgID })
+	maps.DeleteFunc(f.attributes, func(_ string, a *fakeAttribute) bool { return a.orgID == orgID })
+	maps.DeleteFunc(f.groups, func(_ string, g *fakeGroup) bool { return g.orgID == orgID })
+	maps.DeleteFunc(f.plans, func(_ string, p *fakePlan) bool { return p.orgID == orgID })
+	maps.DeleteFunc(f.bundles, func(_ string, b *fakeBundle) bool { return b.orgID == orgID })
+	maps.DeleteFunc(f.invitations, func(_ string, inv *fakeInvitation) bool { return inv.orgID == orgID })
+	maps.DeleteFunc(f.jobs, func(_ string, j *fakeJob) bool { return j.orgID == orgID })
+	maps.DeleteFunc(f.enrollments, func(_ string, e *fakeEnrollment) bool { return e.orgID == orgID })
+	maps.DeleteFunc(f.liEnrollments, func(_ string, e *fakeLIEnrollment) bool { return e.orgID == orgID })
+	maps.DeleteFunc(f.cardEnrollments, func(_ string, e *fakeLIEnrollment) bool { return e.orgID == orgID })
+
+	maps.DeleteFunc(f.courses, func(_ string, c *fakeCourse) bool { return c.orgID == orgID })
+	maps.DeleteFunc(f.learningItems, func(_ string, li *fakeLearningItem) bool { return f.courses[li.courseID] == nil })
+	maps.DeleteFunc(f.cards, func(_ string, c *fakeCard) bool { return f.learningItems[c.learningItemID] == nil })
+
+	maps.DeleteFunc(f.accessTokens, func(_ string, u *fakeUser) bool { return u.orgID == orgID })
+	maps.DeleteFunc(f.contextTokens, func(_ string, sess *fakeSession) bool {
+		return sess.orgID == orgID || sess.user.orgID == orgID
+	})
+}
+
+func (f *fakePlatform) createContext(sess *fakeSession, r *http.Request) (int, any) {
+	var req struct {
+		OrgID string `json:"org_id"`
+	}
+	if err := decodeFake(r, &req); err != nil {
+		return fakeErr(http.StatusBadRequest, err.Error())
+	}
+
+	if _, ok := f.orgs[req.OrgID]; !ok && req.OrgID != config.superAdminOrgID {
+		return fakeErr(http.StatusNotFound, "could not find organization")
+	}
+
+	if !sess.user.superAdmin && sess.user.orgID != req.OrgID {
+		return fakeErr(http.StatusForbidden, "user does not belong to organization")
+	}
+
+	token := uuid.NewString()
+	f.contextTokens[token] = &fakeSession{user: sess.user, orgID: req.OrgID}
+	return http.StatusCreated, map[string]string{"token": token}
+}
+
+func (f *fakePlatform) createOrganization(sess *fakeSession, r *http.Request) (int, any) {
+	if !sess.user.superAdmin {
+		return fakeErr(http.StatusForbidden, "only super admins can create organizations")
+	}
+
+	var req createOrganizationRequest
+	if err := decodeFake(r, &req); err != nil {
+		return fakeErr(http.StatusBadRequest, err.Error())
+	}
+
+	org := &fakeOrg{organization{ID: uuid.NewString(), Slug: req.Slug, Name: req.Name, Status: req.Status, IDPType: "keycloak"}}
+	org.IDPGroupID = org.ID
+	f.orgs[org.ID] = org
+
+	return http.StatusCreated, createOrganizationResponse{Organization: org.organization}
+}
+
+func (f *fakePlatform) createUser(sess *fakeSession, r *http.Request) (int, any) {
+	if !sess.user.isAdmin() {
+		return fakeErr(http.StatusForbidden, "only admins can create users")
+	}
+
+	var req createUserRequest
+	if err := decodeFake(r, &req); err != nil {
+		return fakeErr(http.StatusBadRequest, err.Error())
+	}
+
+	for _, u := range f.users {
+		if u.orgID == sess.orgID && strings.EqualFold(u.Email, req.Email) {
+			return fakeErr(http.StatusConflict, "user already exists")
+		}
+	}
+
+	u := &fakeUser{
+		user:       user{ID: uuid.NewString(), Email: req.Email, FirstName: req.FirstName, LastName: req.LastName, Roles: req.Roles},
+		orgID:      sess.orgID,
+		attributes: map[string]string{},
+	}
+	f.users[u.ID] = u
+
+	return http.StatusCreated, u.user
+}
+
+func (f *fakePlatform) createAttribute(sess *fakeSession, r *http.Request) (int, any) {
+	var req createOrgAttributeRequest
+	if err := decodeFake(r, &req); err != nil {
+		return fakeErr(http.StatusBadRequest, err.Error())
+	}
+
+	if req.Organization.id() != sess.orgID {
+		return fakeErr(http.StatusForbidden, "organization does not match the context")
+	}
+
+	a := &fakeAttribute{
+		orgAttribute: orgAttribute{ID: uuid.NewString(), Name: req.Name, Type: req.Type, Status: "ACTIVE", AttributeOptions: req.AttributeOptions},
+		orgID:        sess.orgID,
+	}
+	f.attributes[a.ID] = a
+
+	return http.StatusCreated, a.orgAttribute
+}
+
+func (f *fakePlatform) listAttributes(sess *fakeSession, r *http.Request) (int, any) {
+	resp := struct {
+		Attributes []*orgAttribute `json:"attributes"`
+	}{Attributes: []*orgAttribute{}}
+
+	for _, a := range f.attributes {
+		if a.orgID == sess.orgID {
+			resp.Attributes = append(resp.Attributes, &a.orgAttribute)
+		}
+	}
+
+	slices.SortFunc(resp.Attributes, func(a, b *orgAttribute) int { return strings.Compare(a.ID, b.ID) })
+	return http.StatusOK, resp
+}
+
+func (f *fakePlatform) assignUserAttribute(sess *fakeSession, r *http.Request) (int, any) {
+	var req assignUserAttributesRequest
+	if err := decodeFake(r, &req); err != nil {
+		return fakeErr(http.StatusBadRequest, err.Error())
+	}
+
+	u, ok := f.users[req.UserID]
+	if !ok || u.orgID != sess.orgID {
+		return fakeErr(http.StatusNotFound, "could not find user")
+	}
+
+	if a, ok := f.attributes[req.AttributeID]; !ok || a.orgID != sess.orgID {
+		return fakeErr(http.StatusNotFound, "could not find attribute")
+	}
+
+	u.attributes[req.AttributeID] = req.Value
+	f.invite()
+
+	return http.StatusCreated, req
+}
+
+func (f *fakePlatform) createLearningGroup(sess *fakeSession, r *http.Request) (int, any) {
+	var req createLearningGroupRequest
+	if err := decodeFake(r, &req); err != nil {
+		return fakeErr(http.StatusBadRequest, err.Error())
+	}
+
+	g := &fakeGroup{learningGroup: learningGroup{ID: uuid.NewString(), Name: req.Name}, orgID: sess.orgID, filters: req.Attributes}
+	f.groups[g.ID] = g
+
+	return http.StatusCreated, f.groupView(g)
+}
+
+func (f *fakePlatform) groupView(g *fakeGroup) learningGroup {
+	lg := g.learningGroup
+	for _, u := range f.users {
+		if g.matches(u) {
+			lg.UserCount += 1
+		}
+	}
+
+	return lg
+}
+
+func fakeNow() string {
+	return formatEnrollmentTime(time.Now())
+}
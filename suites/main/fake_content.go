@@ -0,0 +1,715 @@
+package main_suite_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const fakeItemsPerPage = 30
+
+type fakeCourse struct {
+	course
+	orgID     string
+	title     string
+	state     string
+	createdBy string
+}
+
+type fakeLearningItem struct {
+	learningItem
+	courseID      string
+	itemType      string
+	name          string
+	points        int
+	sequenceOrder int
+}
+
+type fakeCard struct {
+	card
+	learningItemID  string
+	cardType        string
+	title           string
+	sequenceOrder   int
+	confidenceCheck bool
+	question        *fakeQuestion
+}
+
+// fakeQuestion is what the fake keeps of the question of a quiz card. It
+// scores answers by its own rules rather than with the suite's oracle, so
+// the two can disagree.
+type fakeQuestion struct {
+	blockType  string
+	selectMany bool
+	correct    []string
+}
+
+// fakeCardDefinition is the part of a card request the fake scores with.
+type fakeCardDefinition struct {
+	ConfidenceCheck bool `json:"confidenceCheck"`
+	JSON            struct {
+		ContentBlocks []struct {
+			Type               string `json:"type"`
+			MultipleChoiceType string `json:"multipleChoiceType"`
+			Options            []struct {
+				IsCorrect  bool `json:"isCorrect"`
+				OptionText []struct {
+					Children []struct {
+						Text string `json:"text"`
+					} `json:"children"`
+				} `json:"optionText"`
+			} `json:"options"`
+		} `json:"contentBlocks"`
+	} `json:"json"`
+}
+
+func (d *fakeCardDefinition) question() *fakeQuestion {
+	for _, b := range d.JSON.ContentBlocks {
+		switch b.Type {
+		case blockMultipleChoice, blockTrueFalse, blockFreeResponse:
+		default:
+			continue
+		}
+
+		q := &fakeQuestion{blockType: b.Type, selectMany: b.MultipleChoiceType == choiceSelectMany}
+		for _, o := range b.Options {
+			if !o.IsCorrect {
+				continue
+			}
+
+			var text strings.Builder
+			for _, t := range o.OptionText {
+				for _, c := range t.Children {
+					text.WriteString(c.Text)
+				}
+			}
+			q.correct = append(q.correct, text.String())
+		}
+		slices.Sort(q.correct)
+
+		return q
+	}
+
+	return nil
+}
+
+// score gives a point to an answer naming exactly the correct options, a
+// single one unless several may be selected, and to a free response once
+// approved, whatever it says as long as it says something.
+func (q *fakeQuestion) score(answer []string, approved bool) int32 {
+	if q.blockType == blockFreeResponse {
+		if approved && strings.TrimSpace(strings.Join(answer, "")) != "" {
+			return 1
+		}
+
+		return 0
+	}
+
+	if !q.selectMany && len(answer) != 1 {
+		return 0
+	}
+
+	sorted := slices.Clone(answer)
+	slices.Sort(sorted)
+	if slices.Equal(sorted, q.correct) {
+		return 1
+	}
+
+	return 0
+}
+
+// score returns the points the fake gives an answer on c.
+func (c *fakeCard) score(answer []string, approved bool) int32 {
+	if c == nil || c.question == nil {
+		return 0
+	}
+
+	return c.question.score(answer, approved)
+}
+
+type fakePlan struct {
+	learningPlan
+	orgID  string
+	active bool
+}
+
+type fakeBundle struct {
+	courseBundleURLResponse
+	orgID    string
+	courseID string
+	archive  []byte
+}
+
+type fakeInvitation struct {
+	invitation
+	orgID      string
+	courseID   string
+	enrollment *fakeEnrollment
+}
+
+func (f *fakePlatform) routeContent() {
+	f.route("POST /v1/courses", f.createCourse, true)
+	f.route("PATCH /v1/courses/{id}", f.updateCourse, true)
+	f.route("POST /v1/learning_items", f.createLearningItem, true)
+	f.route("POST /v1/cards", f.createCard, true)
+	f.route("POST /v1/learning_items/{id}/cards", f.createCards, true)
+	f.route("POST /v1/learning_plans", f.createLearningPlan, true)
+	f.route("POST /v1/learning_plans/{id}/courses", f.addCoursesToLearningPlan, true)
+	f.route("POST /v1/learning_plans/{id}/learning_plan_groups", f.addGroupsToLearningPlan, true)
+	f.route("PATCH /v1/learning_plans/{id}", f.activateLearningPlan, true)
+	f.route("GET /v1/invitations", f.listInvitations, true)
+	f.route("POST /v1/course-bundle", f.courseBundle, true)
+	f.route("GET /v1/course-bundle-url/{id}", f.courseBundleURL, true)
+	f.mux.HandleFunc("GET /bundles/{name}", f.downloadBundle)
+}
+
+// orgCourse returns the course if it is visible from the session's org.
+func (f *fakePlatform) orgCourse(sess *fakeSession, courseID string) *fakeCourse {
+	c, ok := f.courses[courseID]
+	if !ok || c.orgID != sess.orgID {
+		return nil
+	}
+
+	return c
+}
+
+func (f *fakePlatform) createCourse(sess *fakeSession, r *http.Request) (int, any) {
+	var req createCourseRequest
+	if err := decodeFake(r, &req); err != nil {
+		return fakeErr(http.StatusBadRequest, err.Error())
+	}
+
+	if req.OrganizationId != sess.orgID {
+		return fakeErr(http.StatusForbidden, "organization does not match the context")
+	}
+
+	id := uuid.NewString()
+	c := &fakeCourse{course: course{IRI: newIRI("courses", id), ID: id}, orgID: sess.orgID, title: req.Title, state: "draft", createdBy: sess.user.ID}
+	f.courses[id] = c
+
+	return http.StatusCreated, c.course
+}
+
+func (f *fakePlatform) updateCourse(sess *fakeSession, r *http.Request) (int, any) {
+	c := f.orgCourse(sess, r.PathValue("id"))
+	if c == nil {
+		return fakeErr(http.StatusNotFound, "could not find course")
+	}
+
+	var req struct {
+		State string `json:"state"`
+		Title string `json:"title"`
+	}
+	if err := decodeFake(r, &req); err != nil {
+		return fakeErr(http.StatusBadRequest, err.Error())
+	}
+
+	if req.State != "" {
+		c.state = req.State
+		f.invite()
+	}
+
+	if req.Title != "" && req.Title != c.title {
+		c.title = req.Title
+
+		// Bundles built from the previous version must be rebuilt.
+		for _, b := range f.bundles {
+			if b.courseID == c.ID && b.BundleStatus == bundleStatusCompleted {
+				b.BundleStatus = bundleStatusInvalidated
+			}
+		}
+	}
+
+	return http.StatusOK, c.course
+}
+
+func (f *fakePlatform) createLearningItem(sess *fakeSession, r *http.Request) (int, any) {
+	var req createLearningItemRequest
+	if err := decodeFake(r, &req); err != nil {
+		return fakeErr(http.StatusBadRequest, err.Error())
+	}
+
+	if f.orgCourse(sess, req.Course.id()) == nil {
+		return fakeErr(http.StatusNotFound, "could not find course")
+	}
+
+	id := uuid.NewString()
+	li := &fakeLearningItem{
+		learningItem:  learningItem{IRI: newIRI("learning_items", id), ID: id, LearningItemVersionID: uuid.NewString()},
+		courseID:      req.Course.id(),
+		itemType:      req.Type,
+		name:          req.Name,
+		points:        req.Points,
+		sequenceOrder: len(f.courseItems(req.Course.id())),
+	}
+	f.learningItems[id] = li
+
+	return http.StatusCreated, li.learningItem
+}
+
+// courseItems returns the learning items of a course in sequence order.
+func (f *fakePlatform) courseItems(courseID string) []*fakeLearningItem {
+	var items []*fakeLearningItem
+	for _, li := range f.learningItems {
+		if li.courseID == courseID {
+			items = append(items, li)
+		}
+	}
+
+	slices.SortFunc(items, func(a, b *fakeLearningItem) int { return a.sequenceOrder - b.sequenceOrder })
+	return items
+}
+
+// itemCards returns the cards of a learning item in the order they were created.
+func (f *fakePlatform) itemCards(learningItemID string) []*fakeCard {
+	var cards []*fakeCard
+	for _, c := range f.cards {
+		if c.learningItemID == learningItemID {
+			cards = append(cards, c)
+		}
+	}
+
+	slices.SortFunc(cards, func(a, b *fakeCard) int { return a.sequenceOrder - b.sequenceOrder })
+	return cards
+}
+
+// addCard stores a card and the question the fake scores answers with.
+func (f *fakePlatform) addCard(learningItemID string, req *createCardRequest) (*fakeCard, error) {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var def fakeCardDefinition
+	if err = json.Unmarshal(b, &def); err != nil {
+		return nil, err
+	}
+
+	id := uuid.NewString()
+	c := &fakeCard{
+		card:            card{IRI: newIRI("cards", id), ID: id},
+		learningItemID:  learningItemID,
+		cardType:        req.Type,
+		title:           req.Title,
+		sequenceOrder:   len(f.itemCards(learningItemID)),
+		confidenceCheck: def.ConfidenceCheck,
+		question:        def.question(),
+	}
+
+	if b, err = json.Marshal(req.JSON); err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &c.JSON); err != nil {
+		return nil, err
+	}
+
+	f.cards[id] = c
+
+	return c, nil
+}
+
+func (f *fakePlatform) orgLearningItem(sess *fakeSession, learningItemID string) *fakeLearningItem {
+	li, ok := f.learningItems[learningItemID]
+	if !ok || f.orgCourse(sess, li.courseID) == nil {
+		return nil
+	}
+
+	return li
+}
+
+func (f *fakePlatform) createCard(sess *fakeSession, r *http.Request) (int, any) {
+	var req createCardRequest
+	if err := decodeFake(r, &req); err != nil {
+		return fakeErr(http.StatusBadRequest, err.Error())
+	}
+
+	li := f.orgLearningItem(sess, req.LearningItem.id())
+	if li == nil {
+		return fakeErr(http.StatusNotFound, "could not find learning item")
+	}
+
+	c, err := f.addCard(li.ID, &req)
+	if err != nil {
+		return fakeErr(http.StatusBadRequest, err.Error())
+	}
+
+	return http.StatusCreated, c.card
+}
+
+func (f *fakePlatform) createCards(sess *fakeSession, r *http.Request) (int, any) {
+	li := f.orgLearningItem(sess, r.PathValue("id"))
+	if li == nil {
+		return fakeErr(http.StatusNotFound, "could not find learning item")
+	}
+
+	var req createCardsRequest
+	if err := decodeFake(r, &req); err != nil {
+		return fakeErr(http.StatusBadRequest, err.Error())
+	}
+
+	var resp struct {
+		Cards []card `json:"cards"`
+	}
+	for _, cr := range req.Cards {
+		c, err := f.addCard(li.ID, cr)
+		if err != nil {
+			return fakeErr(http.StatusBadRequest, err.Error())
+		}
+		resp.Cards = append(resp.Cards, c.card)
+	}
+
+	return http.StatusCreated, resp
+}
+
+func (f *fakePlatform) createLearningPlan(sess *fakeSession, r *http.Request) (int, any) {
+	var req createLearningPlanRequest
+	if err := decodeFake(r, &req); err != nil {
+		return fakeErr(http.StatusBadRequest, err.Error())
+	}
+
+	id := uuid.NewString()
+	p := &fakePlan{learningPlan: learningPlan{IRI: newIRI("learning_plans", id), ID: id, Courses: []course{}, LearningGroups: []learningGroup{}}, orgID: sess.orgID}
+	f.plans[id] = p
+
+	return http.StatusCreated, p.learningPlan
+}
+
+func (f *fakePlatform) orgPlan(sess *fakeSession, planID string) *fakePlan {
+	p, ok := f.plans[planID]
+	if !ok || p.orgID != sess.orgID {
+		return nil
+	}
+
+	return p
+}
+
+func (f *fakePlatform) addCoursesToLearningPlan(sess *fakeSession, r *http.Request) (int, any) {
+	p := f.orgPlan(sess, r.PathValue("id"))
+	if p == nil {
+		return fakeErr(http.StatusNotFound, "could not find learning plan")
+	}
+
+	var req addCoursesToLearningPlanRequest
+	if err := decodeFake(r, &req); err != nil {
+		return fakeErr(http.StatusBadRequest, err.Error())
+	}
+
+	for _, id := range req.Courses {
+		c := f.orgCourse(sess, id)
+		if c == nil {
+			return fakeErr(http.StatusNotFound, "could not find course")
+		}
+		p.Courses = append(p.Courses, c.course)
+	}
+	f.invite()
+
+	return http.StatusOK, p.learningPlan
+}
+
+func (f *fakePlatform) addGroupsToLearningPlan(sess *fakeSession, r *http.Request) (int, any) {
+	p := f.orgPlan(sess, r.PathValue("id"))
+	if p == nil {
+		return fakeErr(http.StatusNotFound, "could not find learning plan")
+	}
+
+	var req addGroupsToLearningPlanRequest
+	if err := decodeFake(r, &req); err != nil {
+		return fakeErr(http.StatusBadRequest, err.Error())
+	}
+
+	for _, id := range req.LearningGroupIDs {
+		g, ok := f.groups[id]
+		if !ok || g.orgID != sess.orgID {
+			return fakeErr(http.StatusNotFound, "could not find learning group")
+		}
+		p.LearningGroups = append(p.LearningGroups, f.groupView(g))
+	}
+	f.invite()
+
+	return http.StatusOK, p.learningPlan
+}
+
+func (f *fakePlatform) activateLearningPlan(sess *fakeSession, r *http.Request) (int, any) {
+	p := f.orgPlan(sess, r.PathValue("id"))
+	if p == nil {
+		return fakeErr(http.StatusNotFound, "could not find learning plan")
+	}
+
+	p.active = true
+	f.invite()
+
+	return http.StatusOK, p.learningPlan
+}
+
+// invite invites every member of the groups of an active learning plan to
+// its published courses, the way the invitation worker does.
+func (f *fakePlatform) invite() {
+	invited := map[string]bool{}
+	for _, inv := range f.invitations {
+		invited[inv.courseID+"/"+inv.InvitedUserID] = true
+	}
+
+	for _, p := range f.plans {
+		if !p.active {
+			continue
+		}
+
+		for _, lg := range p.LearningGroups {
+			for _, u := range f.users {
+				if !f.groups[lg.ID].matches(u) {
+					continue
+				}
+
+				for _, pc := range p.Courses {
+					c := f.courses[pc.ID]
+					if c.state != "published" || invited[c.ID+"/"+u.ID] {
+						continue
+					}
+
+					id := uuid.NewString()
+					f.invitations[id] = &fakeInvitation{
+						invitation: invitation{IRI: newIRI("invitations", id), ID: id, InvitedUserID: u.ID, Status: "PENDING"},
+						orgID:      p.orgID,
+						courseID:   c.ID,
+					}
+					invited[c.ID+"/"+u.ID] = true
+				}
+			}
+		}
+	}
+}
+
+func (f *fakePlatform) orgInvitation(sess *fakeSession, invitationID string) *fakeInvitation {
+	inv, ok := f.invitations[invitationID]
+	if !ok || inv.orgID != sess.orgID {
+		return nil
+	}
+
+	return inv
+}
+
+// fakeCollection serves members as a hydra collection, one page at a time.
+func fakeCollection[T any](r *http.Request, members []T) hydraCollection[T] {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	page = max(page, 1)
+
+	coll := hydraCollection[T]{Members: []T{}, TotalItems: len(members)}
+	start, end := (page-1)*fakeItemsPerPage, page*fakeItemsPerPage
+	if start < len(members) {
+		coll.Members = members[start:min(end, len(members))]
+	}
+
+	link := func(page int) string {
+		q := r.URL.Query()
+		q.Set("page", strconv.Itoa(page))
+		return r.URL.Path + "?" + q.Encode()
+	}
+
+	coll.View = hydraView{ID: link(page), First: link(1), Last: link(max(1, (len(members)+fakeItemsPerPage-1)/fakeItemsPerPage))}
+	if end < len(members) {
+		coll.View.Next = link(page + 1)
+	}
+
+	return coll
+}
+
+func (f *fakePlatform) listInvitations(sess *fakeSession, r *http.Request) (int, any) {
+	q := r.URL.Query()
+
+	var invitations []*invitation
+	for _, inv := range f.invitations {
+		if inv.orgID != sess.orgID {
+			continue
+		}
+
+		if ids := q["courseId[]"]; len(ids) > 0 && !slices.Contains(ids, inv.courseID) {
+			continue
+		}
+
+		if ids := q["invitedUserId[]"]; len(ids) > 0 && !slices.Contains(ids, inv.InvitedUserID) {
+			continue
+		}
+
+		invitations = append(invitations, &inv.invitation)
+	}
+
+	slices.SortFunc(invitations, func(a, b *invitation) int { return strings.Compare(a.ID, b.ID) })
+	return http.StatusOK, fakeCollection(r, invitations)
+}
+
+// updateInvitation changes an invitation in place, for tests of the states
+// an invitation reaches without the gateway offering an endpoint to do so.
+func (f *fakePlatform) updateInvitation(invitationID string, update func(inv *fakeInvitation)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	inv, ok := f.invitations[invitationID]
+	if !ok {
+		return fmt.Errorf("no invitation %s", invitationID)
+	}
+
+	update(inv)
+	return nil
+}
+
+func (f *fakePlatform) courseBundle(sess *fakeSession, r *http.Request) (int, any) {
+	var req courseBundleRequest
+	if err := decodeFake(r, &req); err != nil {
+		return fakeErr(http.StatusBadRequest, err.Error())
+	}
+
+	c := f.orgCourse(sess, req.CourseID)
+	if req.OrgID != sess.orgID || c == nil {
+		return fakeErr(http.StatusNotFound, "could not get course info")
+	}
+
+	var userID string
+	if req.OfflineMode == offlineModePersonal {
+		userID = sess.user.ID
+	}
+
+	var invitations []*invitation
+	for _, inv := range f.invitations {
+		if inv.courseID != c.ID || (userID != "" && inv.InvitedUserID != userID) {
+			continue
+		}
+
+		inv.DownloadedOffline = true
+		invitations = append(invitations, &inv.invitation)
+	}
+	slices.SortFunc(invitations, func(a, b *invitation) int { return strings.Compare(a.ID, b.ID) })
+
+	archive, err := f.bundleArchive(c, req, userID, invitations)
+	if err != nil {
+		return fakeErr(http.StatusInternalServerError, err.Error())
+	}
+
+	id := uuid.NewString()
+	f.bundles[id] = &fakeBundle{
+		courseBundleURLResponse: courseBundleURLResponse{BundleStatus: bundleStatusCompleted},
+		orgID:                   sess.orgID,
+		courseID:                c.ID,
+		archive:                 archive,
+	}
+
+	return http.StatusCreated, courseBundleResponse{JobID: id}
+}
+
+// bundleArchive builds the zip a device downloads, in the layout unpackBundle reads.
+func (f *fakePlatform) bundleArchive(c *fakeCourse, req courseBundleRequest, userID string, invitations []*invitation) ([]byte, error) {
+	bc := bundleCourse{ID: c.ID, OrgID: c.orgID, Title: c.title}
+	files := map[string][]byte{}
+	for _, li := range f.courseItems(c.ID) {
+		bli := &bundleLearningItem{ID: li.ID, Type: li.itemType, Name: li.name, SequenceOrder: li.sequenceOrder, Points: li.points}
+		for _, card := range f.itemCards(li.ID) {
+			bli.Cards = append(bli.Cards, &bundleCard{ID: card.ID, Type: card.cardType, Title: card.title, SequenceOrder: card.sequenceOrder, JSON: card.JSON})
+
+			for _, block := range card.JSON.ContentBlocks {
+				if block.MediaID != nil && *block.MediaID != "" {
+					files[bundleMediaDir+*block.MediaID] = []byte("media " + *block.MediaID)
+				}
+			}
+		}
+		bc.LearningItems = append(bc.LearningItems, bli)
+	}
+
+	var err error
+	if files[bundleCourseFile], err = json.Marshal(bc); err != nil {
+		return nil, err
+	}
+	if files[bundleInvitesFile], err = json.Marshal(invitations); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	manifest := bundleManifest{
+		Version:        "1",
+		OrgID:          req.OrgID,
+		CourseID:       req.CourseID,
+		LearningPlanID: req.LearningPlanID,
+		DeviceID:       req.DeviceID,
+		OfflineMode:    req.OfflineMode,
+		UserID:         userID,
+		CreatedAt:      now.Format(time.RFC3339Nano),
+		ExpiresAt:      now.Add(30 * 24 * time.Hour).Format(time.RFC3339Nano),
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		manifest.Files = append(manifest.Files, bundleFile{Path: name, Size: int64(len(files[name])), SHA256: checksum(files[name])})
+	}
+
+	if files[bundleManifestFile], err = json.Marshal(manifest); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range append(names, bundleManifestFile) {
+		w, err := zw.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = w.Write(files[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (f *fakePlatform) courseBundleURL(sess *fakeSession, r *http.Request) (int, any) {
+	b, ok := f.bundles[r.PathValue("id")]
+	if !ok || b.orgID != sess.orgID {
+		return fakeErr(http.StatusNotFound, "could not find bundle job")
+	}
+
+	// The URL is presigned anew on every call, as object storage URLs are.
+	resp := b.courseBundleURLResponse
+	resp.CourseURL = fmt.Sprintf("%s/bundles/%s.zip?X-Amz-Date=%s&X-Amz-Expires=%d", f.URL, r.PathValue("id"),
+		time.Now().UTC().Format("20060102T150405Z"), int(fakeBundleURLTTL.Seconds()))
+
+	return http.StatusOK, resp
+}
+
+// fakeBundleURLTTL is how long a course URL stays valid, short for a test to
+// be able to outlive it.
+const fakeBundleURLTTL = 3 * time.Second
+
+// downloadBundle stands in for the object storage the bundles are uploaded to.
+func (f *fakePlatform) downloadBundle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	b, ok := f.bundles[strings.TrimSuffix(r.PathValue("name"), ".zip")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if expiresAt, ok := bundleURLExpiry(r.URL.String()); !ok || time.Now().After(expiresAt) {
+		http.Error(w, "Request has expired", http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Length", fmt.Sprint(len(b.archive)))
+	w.Write(b.archive)
+}
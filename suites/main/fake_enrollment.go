@@ -0,0 +1,494 @@
+package main_suite_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	fakeSyncMaxBytes = 1 << 20
+	fakeSyncMaxItems = 500
+
+	// fakeClockSkew is how far in the future a device clock may run.
+	fakeClockSkew = time.Minute
+)
+
+type fakeJob struct {
+	invitationEnrollResponse
+	orgID string
+}
+
+type fakeEnrollment struct {
+	courseEnrollment
+	orgID string
+	items []*fakeLIEnrollment
+}
+
+// fakeLIEnrollment is a stored learning item enrollment. The first device to
+// sync claims it, every other device syncing it gets a duplicate of its own.
+type fakeLIEnrollment struct {
+	learningItemEnrollment
+	orgID       string
+	userID      string
+	enrollment  *fakeEnrollment
+	claimed     bool
+	duplicateOf *fakeLIEnrollment
+	duplicates  []*fakeLIEnrollment
+}
+
+// view copies the enrollment and its cards, so responses never share the
+// fake's state.
+func (li *fakeLIEnrollment) view() *learningItemEnrollment {
+	v := li.learningItemEnrollment
+	v.CardEnrollments = make([]*cardEnrollment, len(li.CardEnrollments))
+	for i, c := range li.CardEnrollments {
+		card := *c
+		card.Answer = slices.Clone(c.Answer)
+		v.CardEnrollments[i] = &card
+	}
+
+	return &v
+}
+
+func (li *fakeLIEnrollment) card(cardID string) *cardEnrollment {
+	for _, c := range li.CardEnrollments {
+		if c.CardId == cardID {
+			return c
+		}
+	}
+
+	return nil
+}
+
+func (e *fakeEnrollment) view() *courseEnrollment {
+	ce := e.courseEnrollment
+	ce.LearningItemEnrollments = nil
+	for _, li := range e.items {
+		ce.LearningItemEnrollments = append(ce.LearningItemEnrollments, li.view())
+	}
+
+	return &ce
+}
+
+func (f *fakePlatform) routeEnrollment() {
+	f.route("POST /v1/invitation-enroll", f.invitationEnroll, true)
+	f.route("GET /v1/invitation-enroll/{id}", f.enrollmentJob, true)
+	f.route("POST /v1/enrollments/clone", f.cloneEnrollment, true)
+	f.route("POST /v1/enrollments/sync", f.syncEnrollments, true)
+	f.route("POST /v1/enrollments/duplicate", f.duplicateEnrollments, true)
+}
+
+func (f *fakePlatform) invitationEnroll(sess *fakeSession, r *http.Request) (int, any) {
+	var req invitationEnrollRequest
+	if err := decodeFake(r, &req); err != nil {
+		return fakeErr(http.StatusBadRequest, err.Error())
+	}
+
+	inv := f.orgInvitation(sess, req.InvitationID)
+	if inv == nil {
+		return fakeErr(http.StatusNotFound, "could not find invitation")
+	}
+
+	job := &fakeJob{invitationEnrollResponse{ID: uuid.NewString(), InvitationID: inv.ID}, sess.orgID}
+	expiresAt, err := time.Parse(time.RFC3339, inv.ExpiresAt)
+	switch {
+	case inv.enrollment != nil:
+		job.Status, job.Message = enrollmentJobAlreadyEnrolled, "invitation is already enrolled"
+	case inv.Status == "REVOKED":
+		job.Status, job.Message = enrollmentJobRevoked, "invitation was revoked"
+	case err == nil && expiresAt.Before(time.Now()):
+		job.Status, job.Message = enrollmentJobExpired, "invitation expired"
+	case f.courses[inv.courseID].state == "archived":
+		job.Status, job.Message = enrollmentJobCourseArchived, "course is archived"
+	default:
+		f.enroll(inv)
+		job.Status = enrollmentJobCompleted
+	}
+	f.jobs[job.ID] = job
+
+	// The job runs in the background: callers only see its outcome by polling.
+	return http.StatusCreated, invitationEnrollResponse{ID: job.ID, InvitationID: inv.ID, Status: enrollmentJobPending}
+}
+
+func (f *fakePlatform) enroll(inv *fakeInvitation) {
+	now := fakeNow()
+	e := &fakeEnrollment{
+		courseEnrollment: courseEnrollment{CourseEnrollmentID: uuid.NewString(), CourseID: inv.courseID, UserID: inv.InvitedUserID, InvitationID: inv.ID},
+		orgID:            inv.orgID,
+	}
+
+	for _, item := range f.courseItems(inv.courseID) {
+		li := &fakeLIEnrollment{
+			learningItemEnrollment: learningItemEnrollment{LearningItemEnrollmentId: uuid.NewString(), CourseEnrollmentId: e.CourseEnrollmentID, LearningItemId: item.ID},
+			orgID:                  inv.orgID,
+			userID:                 inv.InvitedUserID,
+			enrollment:             e,
+		}
+
+		for _, c := range f.itemCards(item.ID) {
+			ce := &cardEnrollment{CardEnrollmentId: uuid.NewString(), LearningItemEnrollmentId: li.LearningItemEnrollmentId, CardId: c.ID, CreatedAt: now}
+			li.CardEnrollments = append(li.CardEnrollments, ce)
+			f.cardEnrollments[ce.CardEnrollmentId] = li
+		}
+
+		e.items = append(e.items, li)
+		f.liEnrollments[li.LearningItemEnrollmentId] = li
+	}
+
+	f.enrollments[e.CourseEnrollmentID] = e
+	inv.enrollment = e
+}
+
+func (f *fakePlatform) enrollmentJob(sess *fakeSession, r *http.Request) (int, any) {
+	job, ok := f.jobs[r.PathValue("id")]
+	if !ok || job.orgID != sess.orgID {
+		return fakeErr(http.StatusNotFound, "could not find enrollment job")
+	}
+
+	return http.StatusOK, job.invitationEnrollResponse
+}
+
+func (f *fakePlatform) cloneEnrollment(sess *fakeSession, r *http.Request) (int, any) {
+	var req cloneEnrollmentRequest
+	if err := decodeFake(r, &req); err != nil {
+		return fakeErr(http.StatusBadRequest, err.Error())
+	}
+
+	if req.InvitationID == "" {
+		return fakeErr(http.StatusBadRequest, "invitationId is required")
+	}
+
+	inv := f.orgInvitation(sess, req.InvitationID)
+	if inv == nil || inv.enrollment == nil {
+		return fakeErr(http.StatusNotFound, "could not find invitation")
+	}
+
+	e := inv.enrollment.view()
+	return http.StatusOK, cloneEnrollmentResponse{
+		CourseEnrollmentID:      e.CourseEnrollmentID,
+		CourseID:                e.CourseID,
+		InvitationID:            e.InvitationID,
+		UserID:                  e.UserID,
+		LearningItemEnrollments: e.LearningItemEnrollments,
+	}
+}
+
+func (f *fakePlatform) orgLIEnrollment(sess *fakeSession, id string) *fakeLIEnrollment {
+	li, ok := f.liEnrollments[id]
+	if !ok || li.orgID != sess.orgID {
+		return nil
+	}
+
+	return li
+}
+
+func (f *fakePlatform) syncEnrollments(sess *fakeSession, r *http.Request) (int, any) {
+	body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, fakeSyncMaxBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			io.Copy(io.Discard, r.Body)
+			return fakeErr(http.StatusRequestEntityTooLarge, "sync payload is too large")
+		}
+		return fakeErr(http.StatusBadRequest, err.Error())
+	}
+
+	var req syncEnrollmentRequest
+	if err = json.Unmarshal(body, &req); err != nil {
+		return fakeErr(http.StatusBadRequest, err.Error())
+	}
+
+	if len(req.LearningItemEnrollments) > fakeSyncMaxItems {
+		return fakeErr(http.StatusRequestEntityTooLarge, "too many learning item enrollments")
+	}
+
+	var resp struct {
+		Results []*syncEnrollmentsResult `json:"results"`
+	}
+	for _, li := range req.LearningItemEnrollments {
+		result := &syncEnrollmentsResult{LearningItemEnrollmentID: li.LearningItemEnrollmentId, Success: true}
+		if stored, msg := f.validateSync(sess, li); msg != "" {
+			result.Success, result.Message = false, msg
+		} else {
+			f.sync(stored, li)
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	return http.StatusOK, resp
+}
+
+func parseSyncTime(v string) (time.Time, string) {
+	if v == "" {
+		return time.Time{}, ""
+	}
+
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return t, syncErrInvalidTimestamp
+	}
+
+	if t.After(time.Now().Add(fakeClockSkew)) {
+		return t, syncErrFutureTimestamp
+	}
+
+	return t, ""
+}
+
+// validateSync checks a whole learning item before anything of it is stored.
+func (f *fakePlatform) validateSync(sess *fakeSession, li *learningItemEnrollment) (*fakeLIEnrollment, string) {
+	stored := f.orgLIEnrollment(sess, li.LearningItemEnrollmentId)
+	if stored == nil || stored.duplicateOf != nil {
+		return nil, syncErrEnrollmentNotFound
+	}
+
+	if !sess.user.isAdmin() && stored.userID != sess.user.ID {
+		return nil, syncErrNotOwner
+	}
+
+	timestamps := []string{li.StartedAt, li.UpdatedAt, li.CompletedAt}
+	for _, c := range li.CardEnrollments {
+		timestamps = append(timestamps, c.CreatedAt, c.StartedAt, c.UpdatedAt, c.CompletedAt)
+	}
+	for _, ts := range timestamps {
+		if _, msg := parseSyncTime(ts); msg != "" {
+			return nil, msg
+		}
+	}
+
+	for _, c := range li.CardEnrollments {
+		card, ok := f.cards[c.CardId]
+		if !ok || card.learningItemID != stored.LearningItemId {
+			return nil, syncErrCardNotFound
+		}
+
+		if len(c.Answer) > 0 && card.question == nil {
+			return nil, syncErrNotQuizCard
+		}
+	}
+
+	return stored, ""
+}
+
+// sync stores li on the enrollment claimed by its device, or on the
+// device's duplicate, and merges it into the claimed enrollment.
+func (f *fakePlatform) sync(stored *fakeLIEnrollment, li *learningItemEnrollment) {
+	if !stored.claimed {
+		stored.claimed = true
+		stored.DeviceId = li.DeviceId
+	}
+
+	if stored.DeviceId != li.DeviceId {
+		var dup *fakeLIEnrollment
+		for _, d := range stored.duplicates {
+			if d.DeviceId == li.DeviceId {
+				dup = d
+			}
+		}
+
+		if dup == nil {
+			dup = f.duplicate(stored, li.DeviceId)
+		}
+
+		f.merge(dup, li)
+	}
+
+	f.merge(stored, li)
+}
+
+func (f *fakePlatform) duplicate(stored *fakeLIEnrollment, deviceID string) *fakeLIEnrollment {
+	dup := &fakeLIEnrollment{
+		learningItemEnrollment: learningItemEnrollment{
+			LearningItemEnrollmentId: uuid.NewString(),
+			CourseEnrollmentId:       stored.CourseEnrollmentId,
+			LearningItemId:           stored.LearningItemId,
+			DeviceId:                 deviceID,
+		},
+		orgID:       stored.orgID,
+		userID:      stored.userID,
+		claimed:     true,
+		duplicateOf: stored,
+	}
+
+	for _, c := range stored.CardEnrollments {
+		dc := &cardEnrollment{CardEnrollmentId: uuid.NewString(), LearningItemEnrollmentId: dup.LearningItemEnrollmentId, CardId: c.CardId, CreatedAt: c.CreatedAt}
+		dup.CardEnrollments = append(dup.CardEnrollments, dc)
+		f.cardEnrollments[dc.CardEnrollmentId] = dup
+	}
+
+	stored.duplicates = append(stored.duplicates, dup)
+	f.liEnrollments[dup.LearningItemEnrollmentId] = dup
+	return dup
+}
+
+func normalizeSyncTime(v string) string {
+	t, msg := parseSyncTime(v)
+	if v == "" || msg == syncErrInvalidTimestamp {
+		return v
+	}
+
+	return formatEnrollmentTime(t)
+}
+
+// merge applies every card of li last writer wins: a card is kept unless
+// the incoming one was updated at the same time or later.
+func (f *fakePlatform) merge(stored *fakeLIEnrollment, li *learningItemEnrollment) {
+	for _, c := range li.CardEnrollments {
+		sc := stored.card(c.CardId)
+		if sc == nil || c.UpdatedAt == "" {
+			continue
+		}
+
+		incoming, _ := parseSyncTime(c.UpdatedAt)
+		if current, _ := parseSyncTime(sc.UpdatedAt); sc.UpdatedAt != "" && incoming.Before(current) {
+			continue
+		}
+
+		sc.Answer = c.Answer
+		sc.ElapsedSec = c.ElapsedSec
+		sc.Confidence = 0
+		if f.cards[c.CardId].confidenceCheck {
+			sc.Confidence = c.Confidence
+		}
+		sc.Progress = c.Progress
+		sc.TotalPoints = c.TotalPoints
+		sc.DeviceID = c.DeviceID
+		sc.StartedAt = normalizeSyncTime(c.StartedAt)
+		sc.UpdatedAt = normalizeSyncTime(c.UpdatedAt)
+		sc.CompletedAt = normalizeSyncTime(c.CompletedAt)
+	}
+
+	if startedAt := normalizeSyncTime(li.StartedAt); startedAt != "" && (stored.StartedAt == "" || earlier(startedAt, stored.StartedAt)) {
+		stored.StartedAt = startedAt
+	}
+
+	if updatedAt := normalizeSyncTime(li.UpdatedAt); updatedAt != "" && (stored.UpdatedAt == "" || earlier(stored.UpdatedAt, updatedAt)) {
+		stored.UpdatedAt = updatedAt
+	}
+
+	f.score(stored)
+}
+
+func earlier(a, b string) bool {
+	ta, _ := parseSyncTime(a)
+	tb, _ := parseSyncTime(b)
+	return ta.Before(tb)
+}
+
+// score recomputes the scores, progress and completion of a learning item
+// enrollment and of its course enrollment.
+func (f *fakePlatform) score(li *fakeLIEnrollment) {
+	li.TotalPoints, li.Progress, li.CompletedAt = 0, 0, ""
+
+	var done int32
+	var completedAt string
+	for _, c := range li.CardEnrollments {
+		c.Score = f.cards[c.CardId].score(c.Answer, c.Approved)
+		li.TotalPoints += c.Score
+
+		if c.Progress > 0 {
+			done += 1
+			if completedAt == "" || earlier(completedAt, c.CompletedAt) {
+				completedAt = c.CompletedAt
+			}
+		}
+	}
+
+	if n := int32(len(li.CardEnrollments)); n > 0 {
+		li.Progress = 100 * done / n
+		if done == n {
+			li.CompletedAt = completedAt
+		}
+	}
+
+	e := li.enrollment
+	if e == nil {
+		return
+	}
+
+	e.TotalPoints, e.Progress, e.StartedAt, e.UpdatedAt, e.CompletedAt = 0, 0, "", "", ""
+	completed := true
+	for _, item := range e.items {
+		e.TotalPoints += item.TotalPoints
+		e.Progress += item.Progress / int32(len(e.items))
+
+		if item.StartedAt != "" && (e.StartedAt == "" || earlier(item.StartedAt, e.StartedAt)) {
+			e.StartedAt = item.StartedAt
+		}
+		if item.UpdatedAt != "" && (e.UpdatedAt == "" || earlier(e.UpdatedAt, item.UpdatedAt)) {
+			e.UpdatedAt = item.UpdatedAt
+		}
+
+		completed = completed && item.CompletedAt != ""
+		if item.CompletedAt != "" && (e.CompletedAt == "" || earlier(e.CompletedAt, item.CompletedAt)) {
+			e.CompletedAt = item.CompletedAt
+		}
+	}
+
+	if !completed {
+		e.CompletedAt = ""
+	}
+}
+
+func (f *fakePlatform) duplicateEnrollments(sess *fakeSession, r *http.Request) (int, any) {
+	var req getDuplicateEnrollmentsRequest
+	if err := decodeFake(r, &req); err != nil {
+		return fakeErr(http.StatusBadRequest, err.Error())
+	}
+
+	resp := struct {
+		LearningItemEnrollments []*learningItemEnrollment `json:"learningItemEnrollments"`
+	}{LearningItemEnrollments: []*learningItemEnrollment{}}
+	for _, id := range req.LearningItemEnrollmentIDs {
+		if li := f.orgLIEnrollment(sess, id); li != nil {
+			for _, dup := range li.duplicates {
+				resp.LearningItemEnrollments = append(resp.LearningItemEnrollments, dup.view())
+			}
+		}
+	}
+
+	return http.StatusOK, resp
+}
+
+// courseEnrollment returns a copy of what the fake stored for a course
+// enrollment, reading its state directly as the suite reads the database of
+// the real platform.
+func (f *fakePlatform) courseEnrollment(courseEnrollmentID string) (*courseEnrollment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	e, ok := f.enrollments[courseEnrollmentID]
+	if !ok {
+		return nil, fmt.Errorf("no course enrollment %s", courseEnrollmentID)
+	}
+
+	return e.view(), nil
+}
+
+// approveCardEnrollment approves a free response card enrollment and scores
+// its learning item again, as a reviewer approving the answer would.
+func (f *fakePlatform) approveCardEnrollment(cardEnrollmentID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	li, ok := f.cardEnrollments[cardEnrollmentID]
+	if !ok {
+		return fmt.Errorf("no card enrollment %s", cardEnrollmentID)
+	}
+
+	for _, c := range li.CardEnrollments {
+		if c.CardEnrollmentId == cardEnrollmentID {
+			c.Approved = true
+		}
+	}
+	f.score(li)
+
+	return nil
+}
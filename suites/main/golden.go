@@ -0,0 +1,161 @@
+package main_suite_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files with the responses of the run")
+
+// goldenDir holds the golden files, one per compared response, under fake for
+// the responses of the fake platform and gateway for those of the gateway. The
+// two are kept apart as the fake answers with its own shape.
+const goldenDir = "./testdata/golden"
+
+func goldenPath(fakePlatform bool, name string) string {
+	if fakePlatform {
+		return filepath.Join(goldenDir, "fake", name)
+	}
+
+	return filepath.Join(goldenDir, "gateway", name)
+}
+
+// volatileKeys are the fields replaced whatever their value.
+var volatileKeys = map[string]string{
+	"access_token":  "{token}",
+	"refresh_token": "{token}",
+	"id_token":      "{token}",
+	"token":         "{token}",
+	"jobId":         "{jobId}",
+}
+
+// unorderedKeys are the arrays the platform returns in no set order, sorted
+// before comparing. The others keep their order: sync results follow the
+// order of the request and card enrollments the position of their card.
+var unorderedKeys = map[string]bool{
+	"learningItemEnrollments": true,
+}
+
+// goldenNormalizer replaces the values of a response that differ from one run
+// to the other. Ids are numbered in the order they appear once the unordered
+// arrays are sorted, so a golden file still tells when two fields hold the
+// same id.
+type goldenNormalizer struct {
+	ids map[string]string
+}
+
+// normalizeGolden returns the JSON body as indented JSON, its unordered
+// arrays sorted and its volatile values replaced with placeholders.
+func normalizeGolden(body []byte) ([]byte, error) {
+	var decoded any
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+	if err := d.Decode(&decoded); err != nil {
+		return nil, err
+	}
+
+	decoded, err := sortGolden("", decoded)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &goldenNormalizer{ids: map[string]string{}}
+	b, err := json.MarshalIndent(n.value("", decoded), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(b, '\n'), nil
+}
+
+// sortGolden sorts the arrays of v under unorderedKeys, innermost first, by
+// their elements with the ids and timestamps masked.
+func sortGolden(key string, v any) (any, error) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k := range v {
+			sorted, err := sortGolden(k, v[k])
+			if err != nil {
+				return nil, err
+			}
+			v[k] = sorted
+		}
+	case []any:
+		for i := range v {
+			sorted, err := sortGolden(key, v[i])
+			if err != nil {
+				return nil, err
+			}
+			v[i] = sorted
+		}
+		if !unorderedKeys[key] {
+			return v, nil
+		}
+
+		type element struct {
+			key   string
+			value any
+		}
+
+		elements := make([]element, len(v))
+		for i := range v {
+			b, err := json.Marshal(v[i])
+			if err != nil {
+				return nil, err
+			}
+
+			masked := timestampPattern.ReplaceAllString(string(b), "{timestamp}")
+			elements[i] = element{uuidPattern.ReplaceAllString(masked, "{id}"), v[i]}
+		}
+
+		slices.SortStableFunc(elements, func(a, b element) int {
+			return strings.Compare(a.key, b.key)
+		})
+		for i, e := range elements {
+			v[i] = e.value
+		}
+	}
+
+	return v, nil
+}
+
+func (n *goldenNormalizer) value(key string, v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		// Fields are walked sorted, the order they are written in.
+		for _, k := range sortedKeys(v) {
+			v[k] = n.value(k, v[k])
+		}
+		return v
+	case []any:
+		for i := range v {
+			v[i] = n.value(key, v[i])
+		}
+		return v
+	case string:
+		return n.string(key, v)
+	}
+
+	return v
+}
+
+func (n *goldenNormalizer) string(key, s string) string {
+	if placeholder, ok := volatileKeys[key]; ok && s != "" {
+		return placeholder
+	}
+
+	s = timestampPattern.ReplaceAllString(s, "{timestamp}")
+	return uuidPattern.ReplaceAllStringFunc(s, func(id string) string {
+		id = strings.ToLower(id)
+		if _, ok := n.ids[id]; !ok {
+			n.ids[id] = fmt.Sprintf("{id:%d}", len(n.ids)+1)
+		}
+
+		return n.ids[id]
+	})
+}
@@ -0,0 +1,529 @@
+package main_suite_test
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// harness is embedded by every suite: it connects to the platform, or to the
+// fake one of the run, logs the super admin in and deletes the orgs the suite
+// created. Suites provision the rest of what they need in their SetupSuite.
+type harness struct {
+	suite.Suite
+
+	db         *sql.DB
+	dbq        *dbQuery
+	apiClient  *apiClient
+	keycloak   *keycloakCli
+	superAdmin userCredentials
+
+	// orgs are deleted by TearDownSuite, testOrgs by AfterTest.
+	orgs     []*organization
+	testOrgs []*organization
+
+	// homeOrg is the org whose users the suite's tests act as. Snapshots
+	// leave it and the test's own orgs out: a write anywhere else, the
+	// suite's other orgs included, is reported.
+	homeOrg *organization
+
+	snapshotTables []snapshotTable
+	snapshot       dbSnapshot
+
+	// worlds counts the worlds built since the current test began.
+	worlds int
+}
+
+var (
+	harnessOnce sync.Once
+	harnessErr  error
+
+	// fake is the fake platform of the run, shared by the suites.
+	fake *fakePlatform
+)
+
+// setupRun loads the configuration and creates what the suites of the run
+// share: the fake platform, the cassettes, the contract checker, the route
+// inventory, the coverage, latency and run recorders and the parallel slots.
+func setupRun() error {
+	harnessOnce.Do(func() {
+		harnessErr = startRun()
+	})
+
+	return harnessErr
+}
+
+func startRun() error {
+	// The fake platform and replays need no .env, but one still overrides the defaults.
+	envErr := godotenv.Load("../../.env")
+	loadConfig()
+	replay := config.cassetteMode == cassetteReplay
+	if !(config.fakePlatform || replay) || !errors.Is(envErr, fs.ErrNotExist) {
+		if envErr != nil {
+			return envErr
+		}
+	}
+
+	var err error
+	if config.parallel != "" {
+		if parallelSlots, err = newParallelSlots(config.parallel); err != nil {
+			return err
+		}
+	}
+
+	if config.fakePlatform {
+		fake = startFakePlatform()
+		config.apiGatewayURL, config.keycloakURL, config.contextsURL = fake.URL, fake.URL, fake.URL
+	}
+
+	if config.cassetteMode != "" {
+		if cassettes, err = newCassettePlayer(config.cassetteMode, config.cassetteDir); err != nil {
+			return err
+		}
+	}
+
+	if config.contractCheck != "" {
+		if contracts, err = newContractChecker(config.contractSpec); err != nil {
+			return err
+		}
+	}
+
+	if gatewayRoutes, err = loadRouteInventory(config.routeInventory); err != nil {
+		return err
+	}
+
+	if config.coverageReport != "" {
+		coverage = newCoverageRecorder(gatewayRoutes)
+	}
+
+	if config.latencyCheck != "" {
+		// Replayed responses come back at once, whatever the platform took.
+		if replay {
+			return fmt.Errorf("LATENCY_CHECK cannot be combined with CASSETTE_MODE=%s", cassetteReplay)
+		}
+
+		if latency, err = newLatencyRecorder(config.latencyBudgets); err != nil {
+			return err
+		}
+	}
+
+	if config.reportDir != "" {
+		if reports, err = newRunRecorder(config.reportDir); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// TestMain writes the coverage and run reports, prints the latencies of the
+// run and closes the fake platform once every suite ran.
+func TestMain(m *testing.M) {
+	code := m.Run()
+
+	if coverage != nil {
+		if err := writeCoverageReport(); err != nil {
+			fmt.Fprintln(os.Stderr, "could not write the coverage report:", err)
+			code = 1
+		}
+	}
+
+	if reports != nil {
+		if err := reports.write(); err != nil {
+			fmt.Fprintln(os.Stderr, "could not write the run report:", err)
+			code = 1
+		} else {
+			fmt.Println("run report written to", reports.dir)
+		}
+	}
+
+	if latency != nil {
+		fmt.Println("latencies:")
+		latency.writeSummary(os.Stdout)
+	}
+
+	if fake != nil {
+		fake.Close()
+	}
+
+	os.Exit(code)
+}
+
+func openDB() (*sql.DB, error) {
+	cfg := mysql.Config{
+		User:                 config.dbUser,
+		Passwd:               config.dbPassword,
+		Net:                  "tcp",
+		Addr:                 fmt.Sprintf("%s:%s", config.dbHost, config.dbPort),
+		AllowNativePasswords: true,
+	}
+	// Get a database handle.
+	db, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Ping()
+	return db, err
+}
+
+// name qualifies a test or hook name with the suite's, for the cassettes,
+// the coverage report and the logs.
+func (s *harness) name(testName string) string {
+	suiteName, _, _ := strings.Cut(s.T().Name(), "/")
+	return suiteName + "/" + testName
+}
+
+// begin attributes the following calls to testName and numbers its worlds
+// from one, switching to its cassette when CASSETTE_MODE is set.
+func (s *harness) begin(testName string) {
+	s.apiClient.test = s.name(testName)
+	s.worlds = 0
+
+	if cassettes != nil {
+		s.Require().Nil(cassettes.insert(s.name(testName)))
+	}
+}
+
+func (s *harness) SetupSuite() {
+	s.Require().Nil(setupRun())
+
+	s.apiClient = newApiClient()
+	s.begin("SetupSuite")
+	s.keycloak = newKeycloakCli()
+
+	var err error
+	if !config.fakePlatform && config.cassetteMode != cassetteReplay {
+		s.db, err = openDB()
+		s.Require().Nil(err)
+		s.dbq = newDBQuery(s.db)
+	}
+
+	if config.dbSnapshot != "" && s.db != nil {
+		s.snapshotTables, err = parseSnapshotTables(config.dbSnapshotTables)
+		s.Require().Nil(err)
+	}
+
+	s.superAdmin, err = userLogin(config.superAdminEmail, config.superAdminPassword, config.superAdminOrgID, false)
+	s.Require().Nil(err)
+}
+
+// BeforeTest reports the contract and latency violations of the suite's
+// setup, then attributes the following calls to the test, starts its record
+// in the run report and captures the snapshot tables when DB_SNAPSHOT is set.
+func (s *harness) BeforeTest(_, testName string) {
+	s.reportViolations("SetupSuite")
+	s.begin(testName)
+
+	if reports != nil {
+		reports.start(s.name(testName))
+	}
+
+	if s.snapshotTables == nil {
+		return
+	}
+
+	var err error
+	s.snapshot, err = s.dbq.snapshot(s.snapshotTables, s.ownOrgIDs()...)
+	s.Require().Nil(err)
+}
+
+// ownOrgIDs returns the orgs the running test may write to: the suite's
+// home org and the orgs the test created.
+func (s *harness) ownOrgIDs() []string {
+	var orgIDs []string
+	if s.homeOrg != nil {
+		orgIDs = append(orgIDs, s.homeOrg.ID)
+	}
+	for _, o := range s.testOrgs {
+		orgIDs = append(orgIDs, o.ID)
+	}
+
+	return orgIDs
+}
+
+// AfterTest reports the responses of the test that do not match the gateway
+// contract or exceed their latency budget, and the rows it wrote outside of
+// the home org and its own orgs: with CONTRACT_CHECK=fail, LATENCY_CHECK=fail or
+// DB_SNAPSHOT=fail they fail the test, otherwise they are only logged.
+// It then deletes the orgs the test created, before the test's cassette is
+// ejected, and records how the test ended in the run report.
+func (s *harness) AfterTest(_, testName string) {
+	if reports != nil {
+		defer func() {
+			reports.finish(s.name(testName), testStatus(s.T()))
+		}()
+	}
+
+	if cassettes != nil {
+		defer func() {
+			s.Assert().Nil(cassettes.eject())
+		}()
+	}
+
+	defer func() {
+		for _, o := range s.testOrgs {
+			s.deleteOrg(o)
+		}
+		s.testOrgs = nil
+	}()
+
+	s.reportViolations(testName)
+
+	if s.snapshot == nil {
+		return
+	}
+
+	after, err := s.dbq.snapshot(s.snapshotTables, s.ownOrgIDs()...)
+	s.Require().Nil(err)
+
+	changes := after.diff(s.snapshot)
+	s.snapshot = nil
+
+	for _, c := range changes {
+		if config.dbSnapshot == dbSnapshotFail {
+			s.Assert().Fail("unexpected write outside the test's orgs", "%s: %s", testName, c)
+			continue
+		}
+
+		s.T().Logf("%s: unexpected write outside the test's orgs: %s", testName, c)
+	}
+}
+
+func (s *harness) TearDownSuite() {
+	s.begin("TearDownSuite")
+	if cassettes != nil {
+		defer func() {
+			s.Assert().Nil(cassettes.eject())
+		}()
+	}
+
+	for _, o := range s.orgs {
+		s.deleteOrg(o)
+	}
+
+	if s.db != nil {
+		s.Assert().Nil(s.db.Close())
+	}
+
+	s.reportViolations("SetupSuite")
+	s.reportViolations("TearDownSuite")
+}
+
+// writeCoverageReport saves the gateway coverage of the run to COVERAGE_REPORT.
+func writeCoverageReport() error {
+	report := coverage.report()
+	if err := report.write(config.coverageReport); err != nil {
+		return err
+	}
+
+	fmt.Printf("gateway coverage: %d of %d routes called, report written to %s\n",
+		len(report.Covered), len(report.Covered)+len(report.UntestedRoutes), config.coverageReport)
+	return nil
+}
+
+func (s *harness) reportViolations(name string) {
+	s.reportContractViolations(name)
+	s.reportLatencyViolations(name)
+}
+
+func (s *harness) reportContractViolations(name string) {
+	if contracts == nil {
+		return
+	}
+
+	for _, v := range contracts.drain(s.name(name)) {
+		if config.contractCheck == contractCheckFail {
+			s.Assert().Fail("response does not match the gateway contract", "%s: %s", name, v)
+			continue
+		}
+
+		s.T().Logf("%s: response does not match the gateway contract: %s", name, v)
+	}
+}
+
+func (s *harness) reportLatencyViolations(name string) {
+	if latency == nil {
+		return
+	}
+
+	for _, v := range latency.drain(s.name(name)) {
+		if config.latencyCheck == latencyCheckFail {
+			s.Assert().Fail("latency budget exceeded", "%s: %s", name, v)
+			continue
+		}
+
+		s.T().Logf("%s: latency budget exceeded: %s", name, v)
+	}
+}
+
+// attach saves v as an artifact of the running test when REPORT_DIR is set.
+func (s *harness) attach(name string, v any) {
+	if reports != nil {
+		s.Assert().Nil(reports.attach(s.apiClient.test, name, v))
+	}
+}
+
+// assertGolden compares the response body, its volatile values normalized, to
+// the golden file name of the platform run against, or rewrites the file when
+// the tests run with -update. The gateway has no golden files until they are
+// created against it, the comparison being skipped until then.
+func (s *harness) assertGolden(name string, body []byte) {
+	got, err := normalizeGolden(body)
+	s.Require().Nil(err)
+
+	path := goldenPath(config.fakePlatform, name)
+	if *updateGolden {
+		s.Require().Nil(os.MkdirAll(filepath.Dir(path), 0755))
+		s.Require().Nil(os.WriteFile(path, got, 0644))
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !config.fakePlatform {
+		s.T().Logf("no golden file %s, run the tests with -update against the gateway to create it", path)
+		return
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		s.Failf("missing golden file", "%s does not exist, run the tests with -update to create it", path)
+		return
+	}
+	s.Require().Nil(err)
+
+	s.Assert().Equal(string(want), string(got), "response differs from %s, run the tests with -update if the change is expected", path)
+}
+
+// reportingT passes the failures of the running test on to its testing.T,
+// keeping their messages for the run report.
+type reportingT struct {
+	*testing.T
+	test string
+}
+
+func (t reportingT) Errorf(format string, args ...any) {
+	t.Helper()
+	msg := fmt.Sprintf(format, args...)
+	reports.fail(t.test, msg)
+	t.T.Error(msg)
+}
+
+// Assert and Require shadow those of suite.Suite, to report the failures of
+// the assertions when a run report is written.
+func (s *harness) Assert() *assert.Assertions {
+	if reports == nil || s.apiClient == nil {
+		return s.Suite.Assert()
+	}
+
+	return assert.New(reportingT{s.T(), s.apiClient.test})
+}
+
+func (s *harness) Require() *require.Assertions {
+	if reports == nil || s.apiClient == nil {
+		return s.Suite.Require()
+	}
+
+	return require.New(reportingT{s.T(), s.apiClient.test})
+}
+
+func testStatus(t *testing.T) string {
+	switch {
+	case t.Skipped():
+		return testSkipped
+	case t.Failed():
+		return testFailed
+	}
+
+	return testPassed
+}
+
+// recordJob times an async job of kind since start against its completion
+// budget.
+func (s *harness) recordJob(kind, jobID string, start time.Time) {
+	if latency != nil {
+		latency.job(s.apiClient.test, kind, jobID, time.Since(start))
+	}
+}
+
+// deleteOrg removes an org created by the suite and its realm, then checks
+// that no row still references the deleted org.
+func (s *harness) deleteOrg(o *organization) {
+	if s.db != nil {
+		_, err := s.db.Exec("delete from organization.organization where slug = ?", o.Slug)
+		s.Assert().Nil(err)
+		s.assertNoOrphans(organizationTable, o.ID)
+	}
+
+	err := s.keycloak.deleteRealm(o.ID)
+	s.Assert().Nil(err)
+}
+
+// requireDB skips tests reading the database when there is none: against
+// the fake platform and on replays.
+func (s *harness) requireDB() {
+	switch {
+	case s.db != nil:
+	case config.fakePlatform:
+		s.T().Skip("no database with FAKE_PLATFORM=true")
+	default:
+		s.T().Skip("no database with CASSETTE_MODE=replay")
+	}
+}
+
+// requireFakeRules skips tests checking rules that only the fake platform
+// defines. No documentation or recording of the real platform backs them yet.
+func (s *harness) requireFakeRules() {
+	if !config.fakePlatform {
+		s.T().Skip("checks rules of the fake platform that the real platform does not document")
+	}
+}
+
+// waitForInvitationIn waits for a learning plan of the org of admin to invite
+// userID to the course.
+func (s *harness) waitForInvitationIn(admin userCredentials, courseID, userID string) *invitation {
+	for i := 0; i < 10; i += 1 {
+		invitations, err := s.apiClient.invitations(invitationsFilter{
+			courseID:      courseID,
+			invitedUserID: userID,
+		}, admin)
+		s.Require().Nil(err)
+
+		if len(invitations) > 0 {
+			s.Require().Equal(1, len(invitations))
+			return invitations[0]
+		}
+
+		time.Sleep(time.Second * 5)
+	}
+
+	s.Require().FailNow("no invitation for user " + userID)
+	return nil
+}
+
+func (s *harness) httpCode(err error, code int, msg ...string) {
+	var httpErr *httpError
+
+	s.Require().True(errors.As(err, &httpErr))
+
+	s.Assert().Equal(code, httpErr.code)
+
+	if len(msg) > 0 {
+		s.Assert().Contains(err.Error(), msg[0])
+	}
+}
+
+func ptr[T any](t T) *T {
+	return &t
+}
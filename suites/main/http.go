@@ -8,10 +8,15 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 )
 
 type request struct {
-	req *http.Request
+	req      *http.Request
+	body     []byte
+	gateway  bool
+	test     string
+	respBody *[]byte
 }
 
 type requestOpt func(r *request) error
@@ -38,6 +43,7 @@ func withBody(v any) requestOpt {
 		}
 
 		fmt.Println("### body", string(b))
+		r.body = b
 		r.req.Body = io.NopCloser(bytes.NewReader(b))
 		return nil
 	}
@@ -65,6 +71,33 @@ func withContentType(contentType string) requestOpt {
 	}
 }
 
+// withGateway marks a request to the API gateway, whose responses are checked
+// against the contract and counted in the coverage report.
+func withGateway() requestOpt {
+	return func(r *request) error {
+		r.gateway = true
+		return nil
+	}
+}
+
+// withTest attributes a request to test in the contract violations, the
+// coverage, latency and run reports.
+func withTest(test string) requestOpt {
+	return func(r *request) error {
+		r.test = test
+		return nil
+	}
+}
+
+// withResponseBody keeps the raw body of the response in b, with the fields
+// the decoded type leaves out.
+func withResponseBody(b *[]byte) requestOpt {
+	return func(r *request) error {
+		r.respBody = b
+		return nil
+	}
+}
+
 func withQueryParam(key, value string) requestOpt {
 	return func(r *request) error {
 		q := r.req.URL.Query()
@@ -91,7 +124,8 @@ func newHttpError(message string, code int) *httpError {
 }
 
 func (r *request) send(v any) (*http.Response, error) {
-	resp, err := http.DefaultClient.Do(r.req)
+	start := time.Now()
+	resp, err := doRequest(r.req)
 	if err != nil {
 		fmt.Println("### err", err.Error())
 
@@ -104,6 +138,28 @@ func (r *request) send(v any) (*http.Response, error) {
 	}
 
 	fmt.Println("### resp", string(bytes))
+	elapsed := time.Since(start)
+
+	if r.respBody != nil {
+		*r.respBody = bytes
+	}
+
+	if latency != nil {
+		latency.record(r.test, r.req, elapsed)
+	}
+
+	// A load's requests are in the load report.
+	if reports != nil && load.Load() == nil {
+		reports.trace(r.test, r.req, r.body, resp.StatusCode, bytes, elapsed)
+	}
+
+	if r.gateway && contracts != nil {
+		contracts.check(r.test, r.req, resp.StatusCode, bytes)
+	}
+
+	if r.gateway && coverage != nil {
+		coverage.record(r.test, r.req, resp.StatusCode)
+	}
 
 	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest {
 		return nil, newHttpError(string(bytes), resp.StatusCode)
@@ -121,7 +177,7 @@ func (r *request) send(v any) (*http.Response, error) {
 }
 
 func executeHttpRequest(req *http.Request, v any) (*http.Response, error) {
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := doRequest(req)
 	if err != nil {
 		fmt.Println("### err", err.Error())
 
@@ -157,7 +213,7 @@ func login(orgID, username, password string) (string, error) {
 	form.Add("password", password)
 	form.Add("grant_type", "password")
 
-	url := fmt.Sprintf("http://localhost:8080/realms/%s/protocol/openid-connect/token", orgID)
+	url := fmt.Sprintf("%s/realms/%s/protocol/openid-connect/token", config.keycloakURL, orgID)
 	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(form.Encode()))
 	if err != nil {
 		return "", err
@@ -186,7 +242,7 @@ func userContext(orgID, token string, acceptTerms bool) (string, error) {
 		}
 	}
 
-	url := "http://localhost:8050/v1/contexts"
+	url := config.contextsURL + "/v1/contexts"
 	r, err := newRequest(http.MethodPost, url, withBody(reqBody), withHeader("Authorization", fmt.Sprintf("Bearer %s", token)))
 	if err != nil {
 		return "", err
@@ -203,26 +259,8 @@ func userContext(orgID, token string, acceptTerms bool) (string, error) {
 	return contextResponse.Token, nil
 }
 
-type organization struct {
-	ID          string `json:"id"`
-	Slug        string `json:"slug"`
-	Name        string `json:"name"`
-	IDPType     string `json:"idpType"`
-	IDPGroupID  string `json:"idpGroupId"`
-	IDPClientID string `json:"idpClientId"`
-	Status      string `json:"statis"`
-}
-
-type orgAttribute struct {
-	ID               string             `json:"id"`
-	Name             string             `json:"name"`
-	Type             string             `json:"types"`
-	Status           string             `json:"status"`
-	AttributeOptions []*attributeOption `json:"attributeOptions"`
-}
-
 func orgAttributes(idpToken, contextToken string) ([]*orgAttribute, error) {
-	r, err := newRequest(http.MethodGet, "http://localhost:8050/v1/attributes?page=1&itemsPerPage=10&matchStatus=ACTIVE&matchEditable=true",
+	r, err := newRequest(http.MethodGet, config.contextsURL+"/v1/attributes?page=1&itemsPerPage=10&matchStatus=ACTIVE&matchEditable=true",
 		withHeader("Authorization", fmt.Sprintf("Bearer %s", idpToken)),
 		withHeader("x-context-token", contextToken))
 
@@ -0,0 +1,138 @@
+package main_suite_test
+
+import (
+	"net/http"
+	"strings"
+)
+
+const iriPrefix = "/api/"
+
+// iri is a JSON-LD reference to a gateway resource, e.g. /api/courses/{id}.
+type iri string
+
+func newIRI(resource, id string) iri {
+	return iri(iriPrefix + resource + "/" + id)
+}
+
+func (i iri) resource() string {
+	resource, _, _ := strings.Cut(strings.TrimPrefix(string(i), iriPrefix), "/")
+	return resource
+}
+
+func (i iri) id() string {
+	return string(i)[strings.LastIndex(string(i), "/")+1:]
+}
+
+func (i iri) String() string {
+	return string(i)
+}
+
+type hydraView struct {
+	ID    string `json:"@id"`
+	First string `json:"hydra:first"`
+	Last  string `json:"hydra:last"`
+	Next  string `json:"hydra:next"`
+}
+
+type hydraCollection[T any] struct {
+	Members    []T       `json:"hydra:member"`
+	TotalItems int       `json:"hydra:totalItems"`
+	View       hydraView `json:"hydra:view"`
+}
+
+// collectionPage fetches the first page of a hydra collection.
+type collectionPage[T any] func(credentials userCredentials, opts ...requestOpt) (*hydraCollection[T], error)
+
+// collectionIterator walks a hydra collection page by page, following the
+// hydra:view next links until the last page has been read.
+type collectionIterator[T any] struct {
+	cli         *apiClient
+	first       collectionPage[T]
+	credentials userCredentials
+	opts        []requestOpt
+
+	next       string
+	page       []T
+	current    T
+	totalItems int
+	fetched    bool
+	lastErr    error
+}
+
+func newCollectionIterator[T any](cli *apiClient, first collectionPage[T], credentials userCredentials, opts ...requestOpt) *collectionIterator[T] {
+	return &collectionIterator[T]{
+		cli:         cli,
+		first:       first,
+		credentials: credentials,
+		opts:        opts,
+	}
+}
+
+func (it *collectionIterator[T]) fetch() bool {
+	opts := []requestOpt{withHeader("Accept", "application/ld+json")}
+
+	var resp *hydraCollection[T]
+	if !it.fetched {
+		resp, it.lastErr = it.first(it.credentials, append(opts, it.opts...)...)
+	} else {
+		// Next links already carry the query string of the first request.
+		resp = &hydraCollection[T]{}
+		it.lastErr = it.cli.sendRequest(http.MethodGet, strings.TrimPrefix(it.next, it.cli.url), nil, it.credentials, resp, opts...)
+	}
+
+	if it.lastErr != nil {
+		return false
+	}
+
+	it.fetched = true
+	it.page = resp.Members
+	it.totalItems = resp.TotalItems
+	it.next = resp.View.Next
+	if it.next == resp.View.ID {
+		it.next = ""
+	}
+
+	return true
+}
+
+// scan advances to the next member, fetching the following page when needed.
+func (it *collectionIterator[T]) scan() bool {
+	for len(it.page) == 0 {
+		if it.lastErr != nil || (it.fetched && it.next == "") {
+			return false
+		}
+
+		if !it.fetch() {
+			return false
+		}
+	}
+
+	it.current, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+func (it *collectionIterator[T]) value() T {
+	return it.current
+}
+
+// total returns hydra:totalItems, fetching the first page if needed.
+func (it *collectionIterator[T]) total() int {
+	if !it.fetched && it.lastErr == nil {
+		it.fetch()
+	}
+
+	return it.totalItems
+}
+
+func (it *collectionIterator[T]) err() error {
+	return it.lastErr
+}
+
+func (it *collectionIterator[T]) all() ([]T, error) {
+	var members []T
+	for it.scan() {
+		members = append(members, it.value())
+	}
+
+	return members, it.lastErr
+}
@@ -0,0 +1,115 @@
+package main_suite_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hydraPages serves the pages of a collection at /v1/items, their next links
+// absolute URLs as the gateway writes them. Pages listed in failing answer
+// with an error instead.
+func hydraPages(t *testing.T, pages [][]string, failing ...int) *httptest.Server {
+	total := 0
+	for _, p := range pages {
+		total += len(p)
+	}
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		page = max(page, 1)
+		for _, n := range failing {
+			if n == page {
+				http.Error(w, "page unavailable", http.StatusBadGateway)
+				return
+			}
+		}
+
+		link := func(page int) string {
+			q := r.URL.Query()
+			q.Set("page", strconv.Itoa(page))
+			return srv.URL + r.URL.Path + "?" + q.Encode()
+		}
+
+		coll := hydraCollection[string]{Members: pages[page-1], TotalItems: total}
+		coll.View = hydraView{ID: link(page), First: link(1), Last: link(len(pages))}
+		if page < len(pages) {
+			coll.View.Next = link(page + 1)
+		}
+
+		w.Header().Set("Content-Type", "application/ld+json")
+		assert.Nil(t, json.NewEncoder(w).Encode(coll))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func itemsIterator(srv *httptest.Server) *collectionIterator[string] {
+	cli := &apiClient{url: srv.URL}
+	first := func(credentials userCredentials, opts ...requestOpt) (*hydraCollection[string], error) {
+		var resp hydraCollection[string]
+		if err := cli.sendRequest(http.MethodGet, "/v1/items", nil, credentials, &resp, opts...); err != nil {
+			return nil, err
+		}
+
+		return &resp, nil
+	}
+
+	return newCollectionIterator(cli, first, userCredentials{}, withQueryParam("itemsPerPage", "2"))
+}
+
+func TestCollectionIterator(t *testing.T) {
+	// Replays would serve the requests from a cassette rather than the pages.
+	if os.Getenv("CASSETTE_MODE") == cassetteReplay {
+		t.Skip("the iterator's pages are not on the cassettes")
+	}
+
+	t.Run("pages", func(t *testing.T) {
+		it := itemsIterator(hydraPages(t, [][]string{{"a", "b"}, {"c", "d"}, {"e"}}))
+
+		assert.Equal(t, 5, it.total())
+		items, err := it.all()
+		require.Nil(t, err)
+		assert.Equal(t, []string{"a", "b", "c", "d", "e"}, items)
+	})
+
+	t.Run("empty page", func(t *testing.T) {
+		it := itemsIterator(hydraPages(t, [][]string{{"a", "b"}, {}, {"c"}}))
+
+		items, err := it.all()
+		require.Nil(t, err)
+		assert.Equal(t, []string{"a", "b", "c"}, items)
+	})
+
+	t.Run("empty collection", func(t *testing.T) {
+		it := itemsIterator(hydraPages(t, [][]string{{}}))
+
+		items, err := it.all()
+		require.Nil(t, err)
+		assert.Empty(t, items)
+		assert.Zero(t, it.total())
+	})
+
+	t.Run("error mid-iteration", func(t *testing.T) {
+		it := itemsIterator(hydraPages(t, [][]string{{"a", "b"}, {"c", "d"}, {"e"}}, 2))
+
+		items, err := it.all()
+		assert.Equal(t, []string{"a", "b"}, items)
+
+		var httpErr *httpError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusBadGateway, httpErr.code)
+
+		// The iterator stays on the error rather than skipping the page.
+		assert.False(t, it.scan())
+		assert.Equal(t, err, it.err())
+	})
+}
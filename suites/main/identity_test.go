@@ -0,0 +1,104 @@
+package main_suite_test
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+// IdentitySuite covers orgs and users, with two orgs and no content.
+type IdentitySuite struct {
+	harness
+
+	org            *organization
+	otherOrg       *organization
+	otherAdminInfo *user
+}
+
+func (s *IdentitySuite) SetupSuite() {
+	s.harness.SetupSuite()
+
+	b := newWorldBuilder()
+	b.org("school").admin("admin")
+	b.org("other").admin("otherAdmin")
+
+	w := s.setupWorld(b)
+	s.org, s.otherOrg = w.org("school"), w.org("other")
+	s.homeOrg = s.org
+	s.otherAdminInfo = w.user("otherAdmin")
+}
+
+func TestIdentitySuite(t *testing.T) {
+	runSuite[IdentitySuite](t)
+}
+
+// TestCreateOrganization checks the org is created with the slug it was
+// asked for, as the former suite setup did.
+func (s *IdentitySuite) TestCreateOrganization() {
+	slug := s.worldPrefix() + "-created"
+	o, err := s.apiClient.createOrganization(createOrganizationRequest{Slug: slug, Name: "Created", Status: "ACTIVE"}, s.superAdmin)
+	s.Require().Nil(err)
+	s.testOrgs = append(s.testOrgs, o)
+
+	s.Assert().NotEmpty(o.ID)
+	s.Assert().Equal(slug, o.Slug)
+}
+
+// TestCreateUser creates a learner in the school and logs them in.
+func (s *IdentitySuite) TestCreateUser() {
+	superAdmin, err := s.superAdmin.in(s.org.ID)
+	s.Require().Nil(err)
+
+	email := "dborry+e2e_identity_learner@learntowin.com"
+	info, err := s.apiClient.createUser(createUserRequest{
+		Email:     email,
+		FirstName: "David",
+		LastName:  "Borry",
+		Roles:     []string{"ROLE_LEARNER"},
+	}, superAdmin)
+	s.Require().Nil(err)
+	s.Assert().NotEmpty(info.ID)
+	s.Assert().Equal(email, info.Email)
+
+	_, err = userLogin(email, "password", s.org.ID, true)
+	s.Assert().Nil(err)
+}
+
+// TestOrgAttributes checks the admin of an org lists its attributes only,
+// with their options.
+func (s *IdentitySuite) TestOrgAttributes() {
+	b := newWorldBuilder()
+	school := b.org("school")
+	school.admin("admin")
+	school.attribute("color", "Red", "Green", "Blue")
+	other := b.org("other")
+	other.admin("otherAdmin")
+	other.attribute("size", "S", "M")
+
+	w := s.buildWorld(b)
+
+	attributes, err := s.apiClient.orgAttributes(w.as("admin"))
+	s.Require().Nil(err)
+	s.Require().Equal(1, len(attributes))
+	s.Assert().Equal(w.id("color"), attributes[0].ID)
+	s.Assert().Equal(3, len(attributes[0].AttributeOptions))
+}
+
+// TestDeletedOrgCredentials checks the users of a deleted org can neither use
+// the tokens they hold nor log in again.
+func (s *IdentitySuite) TestDeletedOrgCredentials() {
+	b := newWorldBuilder()
+	b.org("deleted").admin("admin")
+
+	w := s.buildWorld(b)
+	o, admin, adminInfo := w.org("deleted"), w.as("admin"), w.user("admin")
+	s.testOrgs = slices.DeleteFunc(s.testOrgs, func(t *organization) bool { return t.ID == o.ID })
+
+	s.deleteOrg(o)
+
+	_, err := s.apiClient.orgAttributes(admin)
+	s.httpCode(err, http.StatusUnauthorized)
+
+	_, err = userLogin(adminInfo.Email, "password", o.ID, false)
+	s.Assert().NotNil(err)
+}
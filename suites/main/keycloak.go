@@ -25,7 +25,7 @@ func (cli *keycloakCli) refreshToken() {
 	form.Add("password", "admin")
 	form.Add("grant_type", "password")
 
-	url := fmt.Sprintf("http://localhost:8080/realms/%s/protocol/openid-connect/token", "master")
+	url := fmt.Sprintf("%s/realms/%s/protocol/openid-connect/token", config.keycloakURL, "master")
 	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(form.Encode()))
 	if err != nil {
 		log.Fatal("could not get keycloak admin token")
@@ -45,7 +45,7 @@ func (cli *keycloakCli) refreshToken() {
 }
 
 func (cli *keycloakCli) deleteRealm(realmID string) error {
-	r, err := newRequest(http.MethodDelete, fmt.Sprintf("http://localhost:8080/admin/realms/%s", realmID),
+	r, err := newRequest(http.MethodDelete, fmt.Sprintf("%s/admin/realms/%s", config.keycloakURL, realmID),
 		withHeader("Authorization", fmt.Sprintf("Bearer %s", cli.adminToken)))
 	if err != nil {
 		return err
@@ -0,0 +1,191 @@
+package main_suite_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	latencyCheckReport = "report"
+	latencyCheckFail   = "fail"
+)
+
+// latencyBudgets are the longest an endpoint may take to answer and an async
+// job to complete, endpoints without a budget of their own getting Default.
+type latencyBudgets struct {
+	Default   time.Duration            `yaml:"default"`
+	Endpoints map[string]time.Duration `yaml:"endpoints"`
+	Jobs      map[string]time.Duration `yaml:"jobs"`
+}
+
+func loadLatencyBudgets(path string) (*latencyBudgets, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var budgets latencyBudgets
+	if err = yaml.Unmarshal(b, &budgets); err != nil {
+		return nil, fmt.Errorf("could not decode %s: %w", path, err)
+	}
+
+	return &budgets, nil
+}
+
+func (b *latencyBudgets) endpoint(endpoint string) time.Duration {
+	if budget, ok := b.Endpoints[endpoint]; ok {
+		return budget
+	}
+
+	return b.Default
+}
+
+// endpointOf names the endpoint of req by its method and path, templated
+// against the route inventory as the coverage report is.
+func endpointOf(req *http.Request) string {
+	path, ok := gatewayPath(req)
+	if !ok {
+		path = req.URL.Path
+	}
+
+	return gatewayRoutes.route(req.Method, path)
+}
+
+type latencyViolation struct {
+	name    string
+	elapsed time.Duration
+	budget  time.Duration
+}
+
+func (v latencyViolation) String() string {
+	return fmt.Sprintf("%s took %s, over its %s budget", v.name, v.elapsed.Round(time.Millisecond), v.budget)
+}
+
+// latencyRecorder times the calls and the async jobs of every test against
+// their budgets, and keeps the violations by test until the test collects them.
+type latencyRecorder struct {
+	mu         sync.Mutex
+	budgets    *latencyBudgets
+	endpoints  map[string][]time.Duration
+	jobs       map[string][]time.Duration
+	violations map[string][]latencyViolation
+}
+
+var latency *latencyRecorder
+
+func newLatencyRecorder(budgetsPath string) (*latencyRecorder, error) {
+	budgets, err := loadLatencyBudgets(budgetsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &latencyRecorder{
+		budgets:    budgets,
+		endpoints:  map[string][]time.Duration{},
+		jobs:       map[string][]time.Duration{},
+		violations: map[string][]latencyViolation{},
+	}, nil
+}
+
+func (l *latencyRecorder) record(test string, req *http.Request, elapsed time.Duration) {
+	endpoint := endpointOf(req)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.endpoints[endpoint] = append(l.endpoints[endpoint], elapsed)
+	if budget := l.budgets.endpoint(endpoint); budget > 0 && elapsed > budget {
+		l.violations[test] = append(l.violations[test], latencyViolation{endpoint, elapsed, budget})
+	}
+}
+
+// job records how long an async job of kind took to complete, jobs without
+// a budget being timed only.
+func (l *latencyRecorder) job(test, kind, jobID string, elapsed time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.jobs[kind] = append(l.jobs[kind], elapsed)
+	if budget := l.budgets.Jobs[kind]; budget > 0 && elapsed > budget {
+		l.violations[test] = append(l.violations[test], latencyViolation{kind + " job " + jobID, elapsed, budget})
+	}
+}
+
+func (l *latencyRecorder) drain(test string) []latencyViolation {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	violations := l.violations[test]
+	delete(l.violations, test)
+	return violations
+}
+
+type latencySummary struct {
+	Name       string
+	Calls      int
+	P50        time.Duration
+	P95        time.Duration
+	Max        time.Duration
+	Budget     time.Duration
+	OverBudget int
+}
+
+func summarize(name string, durations []time.Duration, budget time.Duration) latencySummary {
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	s := latencySummary{
+		Name:   name,
+		Calls:  len(sorted),
+		P50:    percentile(sorted, 50),
+		P95:    percentile(sorted, 95),
+		Max:    sorted[len(sorted)-1],
+		Budget: budget,
+	}
+	for _, d := range sorted {
+		if budget > 0 && d > budget {
+			s.OverBudget += 1
+		}
+	}
+
+	return s
+}
+
+// writeSummary writes the latencies of the run by endpoint, then by job kind.
+func (l *latencyRecorder) writeSummary(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "endpoint\tcalls\tp50 ms\tp95 ms\tmax ms\tbudget\tover budget\t")
+	for _, endpoint := range sortedKeys(l.endpoints) {
+		writeLatencySummary(tw, summarize(endpoint, l.endpoints[endpoint], l.budgets.endpoint(endpoint)))
+	}
+
+	if len(l.jobs) > 0 {
+		fmt.Fprintln(tw, "\t\t\t\t\t\t\t")
+		fmt.Fprintln(tw, "job\tjobs\tp50 ms\tp95 ms\tmax ms\tbudget\tover budget\t")
+		for _, kind := range sortedKeys(l.jobs) {
+			writeLatencySummary(tw, summarize(kind, l.jobs[kind], l.budgets.Jobs[kind]))
+		}
+	}
+	tw.Flush()
+}
+
+func writeLatencySummary(w io.Writer, s latencySummary) {
+	budget := "-"
+	if s.Budget > 0 {
+		budget = s.Budget.String()
+	}
+
+	fmt.Fprintf(w, "%s\t%d\t%.1f\t%.1f\t%.1f\t%s\t%d\t\n", s.Name, s.Calls,
+		milliseconds(s.P50), milliseconds(s.P95), milliseconds(s.Max), budget, s.OverBudget)
+}
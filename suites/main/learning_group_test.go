@@ -0,0 +1,30 @@
+package main_suite_test
+
+import (
+	"testing"
+)
+
+// LearningGroupSuite covers the learning groups built from user attributes.
+// Every test builds its own world, the user counts depending on all the
+// users of the org.
+type LearningGroupSuite struct {
+	harness
+}
+
+// TestLearningGroup checks the group of the Blue learners counts the one
+// learner with the Blue color, as the former suite setup did.
+func (s *LearningGroupSuite) TestLearningGroup() {
+	b := newWorldBuilder()
+	school := b.org("school")
+	school.admin("admin")
+	school.learner("learner").attribute("color", "Blue")
+	school.attribute("color", "Red", "Green", "Blue")
+	school.group("blues").filter("color", "EQ", "Blue")
+
+	w := s.buildWorld(b)
+	s.Assert().Equal(1, w.group("blues").UserCount)
+}
+
+func TestLearningGroupSuite(t *testing.T) {
+	runSuite[LearningGroupSuite](t)
+}
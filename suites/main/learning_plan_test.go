@@ -0,0 +1,66 @@
+package main_suite_test
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// LearningPlanSuite covers the invitations of learning plans, from scenario
+// files and worlds built by the tests.
+type LearningPlanSuite struct {
+	harness
+}
+
+func TestLearningPlanSuite(t *testing.T) {
+	runSuite[LearningPlanSuite](t)
+}
+
+// TestScenarios provisions every scenario of testdata/scenarios and checks
+// the invitations it expects.
+func (s *LearningPlanSuite) TestScenarios() {
+	var paths []string
+	for _, pattern := range []string{"*.yaml", "*.yml", "*.json"} {
+		matches, err := filepath.Glob(filepath.Join("./testdata/scenarios", pattern))
+		s.Require().Nil(err)
+		paths = append(paths, matches...)
+	}
+	s.Require().NotEmpty(paths)
+
+	for _, path := range paths {
+		s.Run(filepath.Base(path), func() {
+			sc, p := s.provisionScenario(path)
+
+			for _, inv := range sc.Expect.Invitations {
+				admin := p.credentials[sc.owner(inv.Course)]
+				invitation := s.waitForInvitationIn(admin, p.id(inv.Course), p.id(inv.User))
+				s.Assert().Equal(p.id(inv.User), invitation.InvitedUserID)
+			}
+		})
+	}
+}
+
+// TestWorldBuilder builds two worlds from the same builder code and checks
+// each one invites its own learner, in its own org.
+func (s *LearningPlanSuite) TestWorldBuilder() {
+	first, second := s.buildWorld(geographyWorld()), s.buildWorld(geographyWorld())
+	s.Require().NotEqual(first.org("school").ID, second.org("school").ID)
+	s.Require().NotEqual(first.org("school").Slug, second.org("school").Slug)
+
+	for _, w := range []*world{first, second} {
+		s.Assert().Equal(1, w.group("blues").UserCount)
+		s.Assert().Equal(3, len(w.cards("intro")))
+		s.Assert().Equal(6, len(w.cards("test")))
+		s.Assert().NotEmpty(w.plan("semester").Courses)
+
+		invitation := s.waitForInvitationIn(w.adminOf("geography"), w.course("geography").ID, w.user("blue").ID)
+		s.Assert().Equal(w.id("blue"), invitation.InvitedUserID)
+	}
+
+	// The worlds do not see each other's content.
+	invitations, err := s.apiClient.invitations(invitationsFilter{
+		courseID:      first.course("geography").ID,
+		invitedUserID: first.user("blue").ID,
+	}, second.as("school"))
+	s.Require().Nil(err)
+	s.Assert().Empty(invitations)
+}
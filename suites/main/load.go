@@ -0,0 +1,381 @@
+package main_suite_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+)
+
+// loadPlan is how many virtual learners the load mode runs, how fast they
+// start and for how long.
+type loadPlan struct {
+	learners int
+	rampUp   time.Duration
+	duration time.Duration
+	syncs    int
+}
+
+func newLoadPlan(learners, rampUp, duration, syncs string) (*loadPlan, error) {
+	var (
+		p   loadPlan
+		err error
+	)
+
+	if p.learners, err = strconv.Atoi(learners); err != nil || p.learners < 1 {
+		return nil, fmt.Errorf("invalid LOAD_LEARNERS %q, expected a number of learners", learners)
+	}
+
+	if p.rampUp, err = time.ParseDuration(rampUp); err != nil {
+		return nil, fmt.Errorf("invalid LOAD_RAMP_UP %q: %w", rampUp, err)
+	}
+
+	if p.duration, err = time.ParseDuration(duration); err != nil {
+		return nil, fmt.Errorf("invalid LOAD_DURATION %q: %w", duration, err)
+	}
+
+	if p.syncs, err = strconv.Atoi(syncs); err != nil || p.syncs < 1 {
+		return nil, fmt.Errorf("invalid LOAD_SYNCS %q, expected a number of syncs", syncs)
+	}
+
+	return &p, nil
+}
+
+// loadRecorder times every request by endpoint while a load runs.
+type loadRecorder struct {
+	mu        sync.Mutex
+	endpoints map[string]*endpointTimes
+}
+
+type endpointTimes struct {
+	durations []time.Duration
+	errors    int
+}
+
+// load is the recorder of the running load, nil when none runs.
+var load atomic.Pointer[loadRecorder]
+
+func newLoadRecorder() *loadRecorder {
+	return &loadRecorder{endpoints: map[string]*endpointTimes{}}
+}
+
+// time sends req with send and records how long the whole response took,
+// counting transport errors and error statuses as errors.
+func (l *loadRecorder) time(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	start := time.Now()
+	resp, err := send(req)
+	if err == nil {
+		var b []byte
+		b, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(b))
+	}
+	elapsed := time.Since(start)
+
+	endpoint := endpointOf(req)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e := l.endpoints[endpoint]
+	if e == nil {
+		e = &endpointTimes{}
+		l.endpoints[endpoint] = e
+	}
+
+	e.durations = append(e.durations, elapsed)
+	if err != nil || resp.StatusCode >= http.StatusBadRequest {
+		e.errors += 1
+	}
+
+	return resp, err
+}
+
+// endpointLoad sums up the requests of an endpoint, durations in milliseconds.
+type endpointLoad struct {
+	Endpoint   string  `json:"endpoint"`
+	Requests   int     `json:"requests"`
+	Errors     int     `json:"errors"`
+	ErrorRate  float64 `json:"errorRate"`
+	Throughput float64 `json:"throughput"`
+	P50        float64 `json:"p50Ms"`
+	P90        float64 `json:"p90Ms"`
+	P99        float64 `json:"p99Ms"`
+	Max        float64 `json:"maxMs"`
+}
+
+type loadReport struct {
+	Learners         int            `json:"learners"`
+	RampUp           string         `json:"rampUp"`
+	Duration         string         `json:"duration"`
+	Iterations       int            `json:"iterations"`
+	FailedIterations int            `json:"failedIterations"`
+	Failures         []string       `json:"failures,omitempty"`
+	Endpoints        []endpointLoad `json:"endpoints"`
+}
+
+// percentile returns the duration below which p percent of sorted fall.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	n := int(float64(len(sorted))*p/100+0.5) - 1
+	return sorted[min(max(n, 0), len(sorted)-1)]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+func (l *loadRecorder) report(elapsed time.Duration) []endpointLoad {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var endpoints []endpointLoad
+	for _, endpoint := range sortedKeys(l.endpoints) {
+		e := l.endpoints[endpoint]
+		sorted := append([]time.Duration(nil), e.durations...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+		endpoints = append(endpoints, endpointLoad{
+			Endpoint:   endpoint,
+			Requests:   len(sorted),
+			Errors:     e.errors,
+			ErrorRate:  float64(e.errors) / float64(len(sorted)),
+			Throughput: float64(len(sorted)) / elapsed.Seconds(),
+			P50:        milliseconds(percentile(sorted, 50)),
+			P90:        milliseconds(percentile(sorted, 90)),
+			P99:        milliseconds(percentile(sorted, 99)),
+			Max:        milliseconds(sorted[len(sorted)-1]),
+		})
+	}
+
+	return endpoints
+}
+
+func (r *loadReport) writeText(w io.Writer) {
+	fmt.Fprintf(w, "Load: %d learners, %s ramp-up, %s, %d iterations, %d failed\n",
+		r.Learners, r.RampUp, r.Duration, r.Iterations, r.FailedIterations)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "endpoint\trequests\terrors\treq/s\tp50 ms\tp90 ms\tp99 ms\tmax ms\t")
+	for _, e := range r.Endpoints {
+		fmt.Fprintf(tw, "%s\t%d\t%.1f%%\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t\n",
+			e.Endpoint, e.Requests, e.ErrorRate*100, e.Throughput, e.P50, e.P90, e.P99, e.Max)
+	}
+	tw.Flush()
+
+	for _, f := range r.Failures {
+		fmt.Fprintf(w, "  %s\n", f)
+	}
+}
+
+// write saves the report as load.txt and load.json in dir.
+func (r *loadReport) write(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(filepath.Join(dir, "load.txt"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r.writeText(f)
+
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, "load.json"), b, 0644)
+}
+
+// Virtual learners poll jobs faster than the tests, polling being part of
+// the load devices put on the platform.
+const (
+	loadPollInterval = time.Second
+	loadPollAttempts = 60
+)
+
+// virtualLearner goes through what a device does after reconnecting: log in,
+// bundle the course, enroll once, clone the enrollment and sync progress.
+type virtualLearner struct {
+	cli          *apiClient
+	admin        userCredentials
+	email        string
+	orgID        string
+	invitationID string
+	bundle       courseBundleRequest
+	enrolled     bool
+}
+
+func (l *virtualLearner) iteration(syncs int) error {
+	credentials, err := userLogin(l.email, "password", l.orgID, true)
+	if err != nil {
+		return fmt.Errorf("login: %w", err)
+	}
+
+	if err = l.bundleCourse(credentials); err != nil {
+		return fmt.Errorf("bundle: %w", err)
+	}
+
+	if !l.enrolled {
+		if err = l.enroll(); err != nil {
+			return fmt.Errorf("enroll: %w", err)
+		}
+		l.enrolled = true
+	}
+
+	clone, err := l.cli.cloneEnrollment(cloneEnrollmentRequest{InvitationID: l.invitationID}, l.admin)
+	if err != nil {
+		return fmt.Errorf("clone: %w", err)
+	}
+
+	if err = l.sync(clone, syncs); err != nil {
+		return fmt.Errorf("sync: %w", err)
+	}
+
+	return nil
+}
+
+func (l *virtualLearner) bundleCourse(credentials userCredentials) error {
+	resp, err := l.cli.courseBundle(l.bundle, credentials)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < loadPollAttempts; i += 1 {
+		status, err := l.cli.courseBundleURL(resp.JobID, credentials)
+		if err != nil {
+			return err
+		}
+
+		switch status.BundleStatus {
+		case bundleStatusCompleted:
+			_, err = downloadBundle(status.CourseURL)
+			return err
+		case bundleStatusFailed, bundleStatusInvalidated:
+			return fmt.Errorf("bundle job %s ended with %s", resp.JobID, status.BundleStatus)
+		}
+
+		time.Sleep(loadPollInterval)
+	}
+
+	return fmt.Errorf("bundle job %s did not finish", resp.JobID)
+}
+
+func (l *virtualLearner) enroll() error {
+	resp, err := l.cli.invitationEnroll(invitationEnrollRequest{InvitationID: l.invitationID}, l.admin)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < loadPollAttempts; i += 1 {
+		if resp, err = l.cli.enrollmentJob(resp.ID, l.admin); err != nil {
+			return err
+		}
+
+		if resp.Status.terminal() {
+			return resp.err()
+		}
+
+		time.Sleep(loadPollInterval)
+	}
+
+	return fmt.Errorf("enrollment job %s did not finish", resp.ID)
+}
+
+// sync sends syncs requests in a row, each one completing another card on
+// the device, the way a device catches up after reconnecting.
+func (l *virtualLearner) sync(clone *cloneEnrollmentResponse, syncs int) error {
+	device, err := newVirtualDevice(l.bundle.DeviceID, clone)
+	if err != nil {
+		return err
+	}
+
+	var cards [][2]string
+	for _, li := range clone.LearningItemEnrollments {
+		for _, c := range li.CardEnrollments {
+			cards = append(cards, [2]string{li.LearningItemId, c.CardId})
+		}
+	}
+
+	if len(cards) == 0 {
+		return fmt.Errorf("no card enrollment in %s", clone.CourseEnrollmentID)
+	}
+
+	for n := 0; n < syncs; n += 1 {
+		card := cards[n%len(cards)]
+		device.answer(card[0], card[1], nil, time.Now())
+
+		results, err := l.cli.syncEnrollments(device.syncRequest(), l.admin)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range results {
+			if !r.Success {
+				return fmt.Errorf("learning item enrollment %s rejected: %s", r.LearningItemEnrollmentID, r.Message)
+			}
+		}
+	}
+
+	return nil
+}
+
+// runLoad starts the learners evenly over the ramp-up and repeats their
+// iterations until the duration is over, timing every request.
+func runLoad(plan *loadPlan, learners []*virtualLearner) *loadReport {
+	recorder := newLoadRecorder()
+	load.Store(recorder)
+	defer load.Store(nil)
+
+	report := &loadReport{Learners: len(learners), RampUp: plan.rampUp.String(), Duration: plan.duration.String()}
+	failures := map[string]int{}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	start := time.Now()
+	end := start.Add(plan.duration)
+	for n, l := range learners {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			time.Sleep(plan.rampUp * time.Duration(n) / time.Duration(len(learners)))
+			for time.Now().Before(end) {
+				err := l.iteration(plan.syncs)
+
+				mu.Lock()
+				report.Iterations += 1
+				if err != nil {
+					report.FailedIterations += 1
+					failures[err.Error()] += 1
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, msg := range sortedKeys(failures) {
+		report.Failures = append(report.Failures, fmt.Sprintf("%d x %s", failures[msg], msg))
+	}
+	report.Endpoints = recorder.report(time.Since(start))
+
+	return report
+}
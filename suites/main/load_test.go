@@ -0,0 +1,63 @@
+package main_suite_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// LoadSuite drives virtual learners through the course concurrently with
+// LOAD_LEARNERS set, and reports the latency, error rate and throughput of
+// every endpoint they call. It is skipped otherwise.
+type LoadSuite struct {
+	courseSuite
+
+	plan *loadPlan
+}
+
+func TestLoadSuite(t *testing.T) {
+	runSuite[LoadSuite](t)
+}
+
+func (s *LoadSuite) SetupSuite() {
+	s.Require().Nil(setupRun())
+	if config.loadLearners == "" {
+		s.T().Skip("set LOAD_LEARNERS to run the load")
+	}
+
+	var err error
+	s.plan, err = newLoadPlan(config.loadLearners, config.loadRampUp, config.loadDuration, config.loadSyncs)
+	s.Require().Nil(err)
+
+	s.courseSuite.SetupSuite()
+}
+
+func (s *LoadSuite) TestLoad() {
+	var learners []*virtualLearner
+	for n := 0; n < s.plan.learners; n += 1 {
+		info, _ := s.newLearner(fmt.Sprintf("load_%d", n))
+		invitation := s.waitForInvitation(s.course.ID, info.ID)
+
+		learners = append(learners, &virtualLearner{
+			cli:          s.apiClient,
+			admin:        s.orgAdmin,
+			email:        info.Email,
+			orgID:        s.org.ID,
+			invitationID: invitation.ID,
+			bundle:       s.offlineBundleRequest(fmt.Sprintf("load-tablet-%d", n), offlineModePersonal),
+		})
+	}
+
+	report := runLoad(s.plan, learners)
+
+	var b bytes.Buffer
+	report.writeText(&b)
+	s.T().Log("\n" + b.String())
+
+	if config.loadReport != "" {
+		s.Require().Nil(report.write(config.loadReport))
+	}
+
+	s.Require().NotEmpty(report.Endpoints)
+	s.Assert().Less(report.FailedIterations, report.Iterations, "every iteration failed")
+}
@@ -1,10 +1,7 @@
 package main_suite_test
 
 import (
-	"encoding/json"
-	"errors"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 )
@@ -13,7 +10,7 @@ const (
 	deviceAID = "device-a-id"
 )
 
-func (s *MainSuite) TestBundleCourseInvalidCourseID() {
+func (s *OfflineSuite) TestBundleCourseInvalidCourseID() {
 	_, err := s.apiClient.courseBundle(courseBundleRequest{
 		OrgID:          s.org.ID,
 		CourseID:       "not-found",
@@ -24,11 +21,11 @@ func (s *MainSuite) TestBundleCourseInvalidCourseID() {
 	s.httpCode(err, 404, "could not get course info")
 }
 
-func (s *MainSuite) TestBundleCourseLearnerFromOtherOrg() {
-	err := s.superAdmin.switchOrg(s.otherOrg.ID)
+func (s *OfflineSuite) TestBundleCourseLearnerFromOtherOrg() {
+	superAdmin, err := s.superAdmin.in(s.otherOrg.ID)
 	s.Require().Nil(err)
 
-	for _, user := range []userCredentials{s.otherAdmin, s.superAdmin} {
+	for _, user := range []userCredentials{s.otherAdmin, superAdmin} {
 		_, err = s.apiClient.courseBundle(courseBundleRequest{
 			OrgID:          s.org.ID,
 			CourseID:       s.course.ID,
@@ -40,36 +37,30 @@ func (s *MainSuite) TestBundleCourseLearnerFromOtherOrg() {
 	}
 }
 
-func (s *MainSuite) TestBundleCourse() {
-	courseBundleResp, err := s.apiClient.courseBundle(courseBundleRequest{
+func (s *OfflineSuite) TestBundleCourse() {
+	bundleReq := courseBundleRequest{
 		OrgID:          s.org.ID,
 		CourseID:       s.course.ID,
 		LearningPlanID: s.learningPlan.ID,
 		DeviceID:       "test-tablet123",
 		OfflineMode:    "SHARED",
-	}, s.orgAdmin)
+	}
+	courseBundleResp, err := s.apiClient.courseBundle(bundleReq, s.orgAdmin)
 	s.Require().Nil(err)
 
-	var resp *courseBundleURLResponse
-	for i := 0; i < 10; i += 1 {
-		resp, err = s.apiClient.courseBundleURL(courseBundleResp.JobID, s.orgAdmin)
-		s.Require().Nil(err)
+	resp := s.waitForBundle(courseBundleResp.JobID, s.orgAdmin)
+	s.Require().Equal(bundleStatusCompleted, resp.BundleStatus)
 
-		if strings.EqualFold(resp.BundleStatus, "BUNDLE_UPLOAD_COMPLETED") {
-			break
-		}
-
-		time.Sleep(time.Second * 5)
-		i += 1
-	}
+	bundle := s.downloadBundle(resp.CourseURL)
+	s.verifyBundle(bundle, bundleReq)
 
-	s.Require().Equal("BUNDLE_UPLOAD_COMPLETED", resp.BundleStatus)
-
-	invitations, err := s.apiClient.invitations(invitationsFilter{
+	invitationsIt := s.apiClient.listInvitations(invitationsFilter{
 		courseID:      s.course.ID,
 		invitedUserID: s.learnerInfo.ID,
 	}, s.orgAdmin)
+	invitations, err := invitationsIt.all()
 	s.Require().Nil(err)
+	s.Require().Equal(1, invitationsIt.total())
 	s.Require().Equal(1, len(invitations))
 	s.Require().Equal(s.learnerInfo.ID, invitations[0].InvitedUserID)
 
@@ -78,22 +69,12 @@ func (s *MainSuite) TestBundleCourse() {
 	_, err = s.apiClient.invitationEnroll(invitationEnrollRequest{InvitationID: "not-found"}, s.orgAdmin)
 	s.httpCode(err, http.StatusNotFound, "could not find invitation")
 
-	invitationEnrollResp, err := s.apiClient.invitationEnroll(invitationEnrollRequest{InvitationID: invitationID}, s.orgAdmin)
+	// The golden files hold the raw responses, with the fields the client
+	// does not decode.
+	var body []byte
+	_, err = s.apiClient.enrollmentJob(s.enroll(invitationID).ID, s.orgAdmin, withResponseBody(&body))
 	s.Require().Nil(err)
-
-	for i := 0; i < 5; i += 1 {
-		invitationEnrollResp, err = s.apiClient.enrollmentJob(invitationEnrollResp.ID, s.orgAdmin)
-		s.Require().Nil(err)
-
-		if strings.EqualFold(invitationEnrollResp.Status, "ENROLLMENT_COMPLETED") {
-			break
-		}
-
-		time.Sleep(time.Second * 5)
-		i += 1
-	}
-
-	s.Require().Equal("ENROLLMENT_COMPLETED", invitationEnrollResp.Status)
+	s.assertGolden("enrollment_job.json", body)
 
 	_, err = s.apiClient.cloneEnrollment(cloneEnrollmentRequest{}, s.orgAdmin)
 	s.httpCode(err, http.StatusBadRequest, "invitationId is required")
@@ -101,25 +82,25 @@ func (s *MainSuite) TestBundleCourse() {
 	_, err = s.apiClient.cloneEnrollment(cloneEnrollmentRequest{InvitationID: "invalid"}, s.orgAdmin)
 	s.httpCode(err, http.StatusNotFound, "could not find invitation")
 
-	cloneEnrollmentResp, err := s.apiClient.cloneEnrollment(cloneEnrollmentRequest{InvitationID: invitationID}, s.orgAdmin)
+	cloneEnrollmentResp, err := s.apiClient.cloneEnrollment(cloneEnrollmentRequest{InvitationID: invitationID}, s.orgAdmin, withResponseBody(&body))
 	s.Require().Nil(err)
 
-	b, _ := json.Marshal(cloneEnrollmentResp)
-	os.WriteFile("./testdata/clonedEnrollmentsPreSync.json", b, 0644)
+	s.attach("clonedEnrollmentsPreSync.json", cloneEnrollmentResp)
+	s.assertGolden("clone_enrollment.json", body)
 
 	learningItemEnrollments := cloneEnrollmentResp.LearningItemEnrollments
 	learningItemEnrollmentIDs := make([]string, len(learningItemEnrollments))
 	for j, li := range learningItemEnrollments {
 		learningItemEnrollmentIDs[j] = li.LearningItemEnrollmentId
 		li.DeviceId = deviceAID
-		li.UpdatedAt = time.Now().Format("2006-01-02T15:04:05.999Z07:00")
-		li.StartedAt = time.Now().Format("2006-01-02T15:04:05.999Z07:00")
+		li.UpdatedAt = formatEnrollmentTime(time.Now())
+		li.StartedAt = formatEnrollmentTime(time.Now())
 
 		for i, c := range li.CardEnrollments {
 			c.DeviceID = deviceAID
-			c.UpdatedAt = time.Now().Format("2006-01-02T15:04:05.999Z07:00")
-			c.StartedAt = time.Now().Format("2006-01-02T15:04:05.999Z07:00")
-			c.CompletedAt = time.Now().Format("2006-01-02T15:04:05.999Z07:00")
+			c.UpdatedAt = formatEnrollmentTime(time.Now())
+			c.StartedAt = formatEnrollmentTime(time.Now())
+			c.CompletedAt = formatEnrollmentTime(time.Now())
 
 			if !strings.EqualFold(li.LearningItemId, s.quiz.ID) {
 				continue
@@ -142,16 +123,18 @@ func (s *MainSuite) TestBundleCourse() {
 
 		}
 	}
-	syncEnrollmentsResp, err := s.apiClient.syncEnrollments(syncEnrollmentRequest{LearningItemEnrollments: learningItemEnrollments}, s.orgAdmin)
+	syncEnrollmentsResp, err := s.apiClient.syncEnrollments(syncEnrollmentRequest{LearningItemEnrollments: learningItemEnrollments}, s.orgAdmin, withResponseBody(&body))
 	s.Require().Nil(err)
 
-	b, _ = json.Marshal(syncEnrollmentsResp)
-	os.WriteFile("./testdata/sync.json", b, 0644)
+	s.attach("sync.json", syncEnrollmentsResp)
+	s.assertGolden("sync.json", body)
 
 	for _, r := range syncEnrollmentsResp {
 		s.Require().True(r.Success, r.Message)
 	}
 
+	s.assertScores(s.assertStoredEnrollment(cloneEnrollmentResp.CourseEnrollmentID, learningItemEnrollments))
+
 	// Override with same device
 	for _, li := range learningItemEnrollments {
 		if !strings.EqualFold(li.LearningItemId, s.quiz.ID) {
@@ -177,26 +160,16 @@ func (s *MainSuite) TestBundleCourse() {
 		}
 	}
 
-	syncEnrollmentsResp, err = s.apiClient.syncEnrollments(syncEnrollmentRequest{LearningItemEnrollments: learningItemEnrollments}, s.orgAdmin)
+	syncEnrollmentsResp, err = s.apiClient.syncEnrollments(syncEnrollmentRequest{LearningItemEnrollments: learningItemEnrollments}, s.orgAdmin, withResponseBody(&body))
 	s.Require().Nil(err)
 
-	b, _ = json.Marshal(syncEnrollmentsResp)
-	os.WriteFile("./testdata/sync2.json", b, 0644)
+	s.attach("sync2.json", syncEnrollmentsResp)
+	s.assertGolden("sync_override.json", body)
 
 	for _, r := range syncEnrollmentsResp {
 		s.Require().True(r.Success, r.Message)
 	}
 
-}
+	s.assertScores(s.assertStoredEnrollment(cloneEnrollmentResp.CourseEnrollmentID, learningItemEnrollments))
 
-func (s *MainSuite) httpCode(err error, code int, msg ...string) {
-	var httpErr *httpError
-
-	s.Require().True(errors.As(err, &httpErr))
-
-	s.Assert().Equal(code, httpErr.code)
-
-	if len(msg) > 0 {
-		s.Assert().Contains(err.Error(), msg[0])
-	}
 }
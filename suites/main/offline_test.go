@@ -0,0 +1,183 @@
+package main_suite_test
+
+import (
+	"testing"
+	"time"
+)
+
+// OfflineSuite covers the offline bundles of the course and the enrollments
+// made from them.
+type OfflineSuite struct {
+	courseSuite
+}
+
+func TestOfflineSuite(t *testing.T) {
+	runSuite[OfflineSuite](t)
+}
+
+func (s *courseSuite) offlineBundleRequest(deviceID, mode string) courseBundleRequest {
+	return courseBundleRequest{
+		OrgID:          s.org.ID,
+		CourseID:       s.course.ID,
+		LearningPlanID: s.learningPlan.ID,
+		DeviceID:       deviceID,
+		OfflineMode:    mode,
+	}
+}
+
+func (s *OfflineSuite) TestOfflineModes() {
+	for _, tc := range []struct {
+		mode        string
+		credentials userCredentials
+		userID      string
+	}{
+		{mode: offlineModeShared, credentials: s.orgAdmin},
+		{mode: offlineModePersonal, credentials: s.learner, userID: s.learnerInfo.ID},
+	} {
+		s.Run(tc.mode, func() {
+			req := s.offlineBundleRequest("tablet-"+tc.mode, tc.mode)
+			_, bundle := s.bundleCourse(req, tc.credentials)
+			s.verifyBundle(bundle, req)
+
+			s.Assert().Equal(tc.userID, bundle.manifest.UserID)
+			if tc.mode == offlineModePersonal {
+				s.Assert().Equal([]string{s.learnerInfo.ID}, bundle.invitedUserIDs())
+			} else {
+				s.Assert().Contains(bundle.invitedUserIDs(), s.learnerInfo.ID)
+			}
+
+			createdAt, err := time.Parse(time.RFC3339, bundle.manifest.CreatedAt)
+			s.Require().Nil(err)
+			expiresAt, err := time.Parse(time.RFC3339, bundle.manifest.ExpiresAt)
+			s.Require().Nil(err)
+			s.Assert().True(expiresAt.After(createdAt))
+			s.Assert().True(expiresAt.After(time.Now()))
+
+			s.Assert().True(s.waitForInvitation(s.course.ID, s.learnerInfo.ID).DownloadedOffline)
+		})
+	}
+}
+
+// maxBundleURLWait is the longest TestOfflineExpiredBundleURL waits for a
+// course URL to expire; platforms presigning for longer skip it.
+const maxBundleURLWait = time.Minute
+
+func (s *OfflineSuite) TestOfflineExpiredBundleURL() {
+	if config.bundleDir != "" || config.bundleBaseURL != "" {
+		s.T().Skip("bundle stand-ins do not expire course URLs")
+	}
+
+	req := s.offlineBundleRequest("tablet-expired", offlineModeShared)
+	courseBundleResp, err := s.apiClient.courseBundle(req, s.orgAdmin)
+	s.Require().Nil(err)
+
+	resp := s.waitForBundle(courseBundleResp.JobID, s.orgAdmin)
+	s.Require().Equal(bundleStatusCompleted, resp.BundleStatus)
+
+	expiresAt, ok := bundleURLExpiry(resp.CourseURL)
+	if !ok {
+		s.T().Skip("course URL is not presigned")
+	}
+	if wait := time.Until(expiresAt); wait > maxBundleURLWait {
+		s.T().Skipf("course URL expires in %s", wait.Round(time.Second))
+	}
+
+	time.Sleep(time.Until(expiresAt) + time.Second)
+	_, err = downloadBundle(resp.CourseURL)
+	s.httpCode(err, 403)
+
+	// The job hands out a fresh URL once the previous one expired.
+	resp, err = s.apiClient.courseBundleURL(courseBundleResp.JobID, s.orgAdmin)
+	s.Require().Nil(err)
+	s.Require().Equal(bundleStatusCompleted, resp.BundleStatus)
+	s.verifyBundle(s.downloadBundle(resp.CourseURL), req)
+}
+
+func (s *OfflineSuite) TestOfflinePersonalMultipleDevices() {
+	devices := []string{"phone-personal", "tablet-personal"}
+	jobIDs := map[string]bool{}
+	for _, deviceID := range devices {
+		req := s.offlineBundleRequest(deviceID, offlineModePersonal)
+		jobID, bundle := s.bundleCourse(req, s.learner)
+		s.verifyBundle(bundle, req)
+
+		jobIDs[jobID] = true
+		s.Assert().Equal(deviceID, bundle.manifest.DeviceID)
+		s.Assert().Equal([]string{s.learnerInfo.ID}, bundle.invitedUserIDs())
+	}
+
+	s.Assert().Equal(len(devices), len(jobIDs))
+
+	// Downloading on several devices must not create extra invitations.
+	invitations, err := s.apiClient.invitations(invitationsFilter{
+		courseID:      s.course.ID,
+		invitedUserID: s.learnerInfo.ID,
+	}, s.orgAdmin)
+	s.Require().Nil(err)
+	s.Require().Equal(1, len(invitations))
+	s.Assert().True(invitations[0].DownloadedOffline)
+}
+
+func (s *OfflineSuite) TestOfflineDeviceSharedAcrossLearners() {
+	const deviceID = "tablet-classroom"
+
+	otherLearnerInfo, otherLearner := s.newLearner("offline_learner")
+	s.waitForInvitation(s.course.ID, otherLearnerInfo.ID)
+
+	req := s.offlineBundleRequest(deviceID, offlineModeShared)
+	_, bundle := s.bundleCourse(req, s.orgAdmin)
+	s.verifyBundle(bundle, req)
+	s.Assert().Empty(bundle.manifest.UserID)
+	s.Assert().Contains(bundle.invitedUserIDs(), s.learnerInfo.ID)
+	s.Assert().Contains(bundle.invitedUserIDs(), otherLearnerInfo.ID)
+
+	// Personal bundles on the same device stay scoped to their learner, in a
+	// fixed order for the cassettes to replay.
+	for _, learner := range []struct {
+		userID      string
+		credentials userCredentials
+	}{
+		{s.learnerInfo.ID, s.learner},
+		{otherLearnerInfo.ID, otherLearner},
+	} {
+		req := s.offlineBundleRequest(deviceID, offlineModePersonal)
+		_, bundle := s.bundleCourse(req, learner.credentials)
+		s.verifyBundle(bundle, req)
+		s.Assert().Equal(learner.userID, bundle.manifest.UserID)
+		s.Assert().Equal([]string{learner.userID}, bundle.invitedUserIDs())
+	}
+}
+
+func (s *OfflineSuite) TestOfflineRebundleAfterCourseEdit() {
+	req := s.offlineBundleRequest("tablet-rebundle", offlineModeShared)
+	jobID, bundle := s.bundleCourse(req, s.orgAdmin)
+	s.verifyBundle(bundle, req)
+	title := bundle.course.Title
+
+	_, err := s.apiClient.updateCourse(s.course.ID, updateCourseRequest{Title: title + " (edited)"}, s.orgAdmin)
+	s.Require().Nil(err)
+	defer func() {
+		_, err := s.apiClient.updateCourse(s.course.ID, updateCourseRequest{Title: title}, s.orgAdmin)
+		s.Assert().Nil(err)
+	}()
+
+	// Editing the course invalidates bundles built from the previous version.
+	var resp *courseBundleURLResponse
+	for i := 0; i < 10; i += 1 {
+		resp, err = s.apiClient.courseBundleURL(jobID, s.orgAdmin)
+		s.Require().Nil(err)
+
+		if resp.BundleStatus == bundleStatusInvalidated {
+			break
+		}
+
+		time.Sleep(time.Second * 5)
+	}
+	s.Assert().Equal(bundleStatusInvalidated, resp.BundleStatus)
+
+	rebundledJobID, rebundled := s.bundleCourse(req, s.orgAdmin)
+	s.verifyBundle(rebundled, req)
+	s.Assert().NotEqual(jobID, rebundledJobID)
+	s.Assert().Equal(title+" (edited)", rebundled.course.Title)
+	s.Assert().NotEqual(bundle.manifest.CreatedAt, rebundled.manifest.CreatedAt)
+}
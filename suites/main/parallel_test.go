@@ -0,0 +1,106 @@
+package main_suite_test
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"regexp"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// parallelSlots limits the tests running at once with PARALLEL set, nil when
+// the suites run one test after the other.
+var parallelSlots chan struct{}
+
+func newParallelSlots(parallel string) (chan struct{}, error) {
+	n, err := strconv.Atoi(parallel)
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("invalid PARALLEL %q, expected the number of tests to run at once", parallel)
+	}
+
+	// Cassettes and snapshots follow a single test through the whole platform.
+	if config.cassetteMode != "" || config.dbSnapshot != "" {
+		return nil, fmt.Errorf("PARALLEL cannot be combined with CASSETTE_MODE or DB_SNAPSHOT")
+	}
+
+	// A load times every request sent while it runs, the other tests' included.
+	if config.loadLearners != "" {
+		return nil, fmt.Errorf("PARALLEL cannot be combined with LOAD_LEARNERS")
+	}
+
+	return make(chan struct{}, n), nil
+}
+
+// parallelSuite is a suite that runSuite can run one test method per instance.
+type parallelSuite interface {
+	suite.TestingSuite
+	suite.SetupAllSuite
+	suite.TearDownAllSuite
+	suite.BeforeTest
+	suite.AfterTest
+}
+
+// runSuite runs the suite S with testify, or with PARALLEL set, runs each of
+// its test methods on its own instance of S, alongside the tests of the other
+// suites. Every instance goes through SetupSuite, so a test gets its own
+// orgs and credentials, and shares nothing with the tests running with it.
+func runSuite[S any, P interface {
+	*S
+	parallelSuite
+}](t *testing.T) {
+	if err := setupRun(); err != nil {
+		t.Fatal(err)
+	}
+
+	if parallelSlots == nil {
+		suite.Run(t, P(new(S)))
+		return
+	}
+
+	t.Parallel()
+
+	match, err := regexp.Compile(flag.Lookup("testify.m").Value.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	suiteType := reflect.TypeOf(P(nil))
+	for i := 0; i < suiteType.NumMethod(); i++ {
+		method := suiteType.Method(i)
+		if !strings.HasPrefix(method.Name, "Test") || !match.MatchString(method.Name) {
+			continue
+		}
+
+		t.Run(method.Name, func(t *testing.T) {
+			t.Parallel()
+
+			parallelSlots <- struct{}{}
+			defer func() {
+				<-parallelSlots
+			}()
+
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("test panicked: %v\n%s", r, debug.Stack())
+				}
+			}()
+
+			s := P(new(S))
+			s.SetT(t)
+			s.SetS(s)
+
+			s.SetupSuite()
+			defer s.TearDownSuite()
+
+			s.BeforeTest(suiteType.Elem().Name(), method.Name)
+			defer s.AfterTest(suiteType.Elem().Name(), method.Name)
+
+			method.Func.Call([]reflect.Value{reflect.ValueOf(s)})
+		})
+	}
+}
@@ -0,0 +1,328 @@
+package main_suite_test
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// httpTrace is a call a test made, scrubbed as cassettes are and without its
+// headers, which carry tokens.
+type httpTrace struct {
+	Method       string          `json:"method"`
+	URL          string          `json:"url"`
+	Status       int             `json:"status"`
+	Elapsed      float64         `json:"elapsedMs"`
+	RequestBody  json.RawMessage `json:"requestBody,omitempty"`
+	ResponseBody json.RawMessage `json:"responseBody,omitempty"`
+}
+
+func (t httpTrace) String() string {
+	return fmt.Sprintf("%s %s -> %d (%.1fms)", t.Method, t.URL, t.Status, t.Elapsed)
+}
+
+// createdResource is an id the platform returned for a POST of a test.
+type createdResource struct {
+	Endpoint string `json:"endpoint"`
+	ID       string `json:"id"`
+}
+
+const (
+	testPassed  = "passed"
+	testFailed  = "failed"
+	testSkipped = "skipped"
+)
+
+// testRecord is what the report keeps of a test, or of a suite's setup and
+// teardown, which have no status.
+type testRecord struct {
+	Name      string            `json:"name"`
+	Status    string            `json:"status,omitempty"`
+	Duration  float64           `json:"durationSeconds"`
+	Traces    []httpTrace       `json:"traces"`
+	Created   []createdResource `json:"created"`
+	Artifacts []string          `json:"artifacts"`
+	Failures  []string          `json:"failures,omitempty"`
+
+	start time.Time
+}
+
+// runRecorder keeps the records of the run's tests and writes their artifacts
+// under a directory of its own, so runs do not overwrite each other.
+type runRecorder struct {
+	mu    sync.Mutex
+	dir   string
+	tests map[string]*testRecord
+}
+
+var reports *runRecorder
+
+func newRunRecorder(baseDir string) (*runRecorder, error) {
+	if err := os.MkdirAll(baseDir, 0755); err != nil {
+		return nil, err
+	}
+
+	// Runs started within the same second get a directory each.
+	dir, err := os.MkdirTemp(baseDir, "run-"+time.Now().Format("20060102-150405")+"-*")
+	if err != nil {
+		return nil, err
+	}
+
+	return &runRecorder{dir: dir, tests: map[string]*testRecord{}}, nil
+}
+
+// test returns the record of test, creating it on first use. Callers hold mu.
+func (r *runRecorder) test(test string) *testRecord {
+	t := r.tests[test]
+	if t == nil {
+		t = &testRecord{Name: test}
+		r.tests[test] = t
+	}
+
+	return t
+}
+
+func (r *runRecorder) start(test string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.test(test).start = time.Now()
+}
+
+func (r *runRecorder) finish(test, status string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := r.test(test)
+	t.Status = status
+	t.Duration = time.Since(t.start).Seconds()
+}
+
+// fail records the message of a failed assertion of test.
+func (r *runRecorder) fail(test, msg string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := r.test(test)
+	t.Failures = append(t.Failures, msg)
+}
+
+// trace records a call of test, and the id in its response when it created
+// something. Calls made outside of a test, such as logins, are left out.
+func (r *runRecorder) trace(test string, req *http.Request, reqBody []byte, status int, respBody []byte, elapsed time.Duration) {
+	if test == "" {
+		return
+	}
+
+	trace := httpTrace{
+		Method:  req.Method,
+		URL:     scrub(req.URL.String()),
+		Status:  status,
+		Elapsed: milliseconds(elapsed),
+	}
+	if json.Valid(reqBody) {
+		trace.RequestBody = json.RawMessage(scrub(string(reqBody)))
+	}
+	if json.Valid(respBody) {
+		trace.ResponseBody = json.RawMessage(scrub(string(respBody)))
+	}
+
+	var created struct {
+		ID string `json:"id"`
+	}
+	if req.Method == http.MethodPost && status < http.StatusBadRequest {
+		json.Unmarshal(respBody, &created)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := r.test(test)
+	t.Traces = append(t.Traces, trace)
+	if created.ID != "" {
+		t.Created = append(t.Created, createdResource{endpointOf(req), created.ID})
+	}
+}
+
+// attach saves v as JSON under the test's directory and lists it in the
+// test's record.
+func (r *runRecorder) attach(test, name string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(r.dir, "artifacts", filepath.FromSlash(test))
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, name)
+	if err = os.WriteFile(path, b, 0644); err != nil {
+		return err
+	}
+
+	rel, err := filepath.Rel(r.dir, path)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := r.test(test)
+	t.Artifacts = append(t.Artifacts, filepath.ToSlash(rel))
+	return nil
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     float64         `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// newJUnitFailure sums up the failures of a test: the error of the first one
+// as message, all of them in full as text.
+func newJUnitFailure(failures []string) *junitFailure {
+	if len(failures) == 0 {
+		return &junitFailure{Message: "test failed, see the test output"}
+	}
+
+	// testify formats failures as "Error:" and "Messages:" lines, the first
+	// of which is the message of a failure coming from elsewhere.
+	f := &junitFailure{Text: strings.Join(failures, "\n")}
+	var first, errorMsg, messages string
+	for _, line := range strings.Split(failures[0], "\n") {
+		line = strings.TrimSpace(line)
+		if msg, ok := strings.CutPrefix(line, "Error:"); ok {
+			errorMsg = strings.TrimSuffix(strings.TrimSpace(msg), ":")
+		} else if msg, ok := strings.CutPrefix(line, "Messages:"); ok {
+			messages = strings.TrimSpace(msg)
+		} else if first == "" {
+			first = line
+		}
+	}
+
+	switch {
+	case errorMsg != "" && messages != "":
+		f.Message = errorMsg + ": " + messages
+	case errorMsg != "":
+		f.Message = errorMsg
+	default:
+		f.Message = first
+	}
+
+	return f
+}
+
+// junit returns the tests of the run by suite, the traces, created ids and
+// artifacts of a test in its system-out.
+func (r *runRecorder) junit() *junitTestSuites {
+	report := &junitTestSuites{}
+	suites := map[string]*junitTestSuite{}
+	for _, name := range sortedKeys(r.tests) {
+		t := r.tests[name]
+		if t.Status == "" {
+			continue
+		}
+
+		suiteName, testName, _ := strings.Cut(name, "/")
+		s := suites[suiteName]
+		if s == nil {
+			s = &junitTestSuite{Name: suiteName}
+			suites[suiteName] = s
+		}
+
+		var out strings.Builder
+		for _, trace := range t.Traces {
+			fmt.Fprintf(&out, "%s\n", trace)
+		}
+		for _, c := range t.Created {
+			fmt.Fprintf(&out, "created %s by %s\n", c.ID, c.Endpoint)
+		}
+		for _, a := range t.Artifacts {
+			fmt.Fprintf(&out, "[[ATTACHMENT|%s]]\n", filepath.Join(r.dir, filepath.FromSlash(a)))
+		}
+
+		c := junitTestCase{ClassName: suiteName, Name: testName, Time: t.Duration, SystemOut: out.String()}
+		switch t.Status {
+		case testFailed:
+			c.Failure = newJUnitFailure(t.Failures)
+			s.Failures += 1
+		case testSkipped:
+			c.Skipped = &struct{}{}
+			s.Skipped += 1
+		}
+
+		s.Tests += 1
+		s.Time += t.Duration
+		s.Cases = append(s.Cases, c)
+	}
+
+	for _, name := range sortedKeys(suites) {
+		s := suites[name]
+		report.Tests += s.Tests
+		report.Failures += s.Failures
+		report.Skipped += s.Skipped
+		report.Suites = append(report.Suites, *s)
+	}
+
+	return report
+}
+
+// write saves the run's report as junit.xml and report.json in its directory.
+func (r *runRecorder) write() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, err := xml.MarshalIndent(r.junit(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err = os.WriteFile(filepath.Join(r.dir, "junit.xml"), append([]byte(xml.Header), b...), 0644); err != nil {
+		return err
+	}
+
+	var tests []*testRecord
+	for _, name := range sortedKeys(r.tests) {
+		tests = append(tests, r.tests[name])
+	}
+
+	if b, err = json.MarshalIndent(map[string]any{"tests": tests}, "", "  "); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(r.dir, "report.json"), b, 0644)
+}
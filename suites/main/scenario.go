@@ -0,0 +1,485 @@
+package main_suite_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// scenario describes orgs and their content to provision before a flow, in
+// YAML or JSON. Every entity has a ref, unique in the scenario, that other
+// entities use to point at it and tests use to get its id.
+type scenario struct {
+	Orgs   []*scenarioOrg `yaml:"orgs" json:"orgs"`
+	Expect struct {
+		Invitations []scenarioInvitation `yaml:"invitations" json:"invitations"`
+	} `yaml:"expect" json:"expect"`
+
+	dir    string
+	owners map[string]string
+}
+
+type scenarioOrg struct {
+	Ref        string               `yaml:"ref" json:"ref"`
+	Slug       string               `yaml:"slug" json:"slug"`
+	Name       string               `yaml:"name" json:"name"`
+	Users      []*scenarioUser      `yaml:"users" json:"users"`
+	Attributes []*scenarioAttribute `yaml:"attributes" json:"attributes"`
+	Groups     []*scenarioGroup     `yaml:"groups" json:"groups"`
+	Courses    []*scenarioCourse    `yaml:"courses" json:"courses"`
+	Plans      []*scenarioPlan      `yaml:"plans" json:"plans"`
+}
+
+// scenarioUser is a user of the org; the first ROLE_ADMIN user provisions the
+// rest of the org. Attributes map attribute refs to the user's value.
+type scenarioUser struct {
+	Ref        string            `yaml:"ref" json:"ref"`
+	Email      string            `yaml:"email" json:"email"`
+	FirstName  string            `yaml:"firstName" json:"firstName"`
+	LastName   string            `yaml:"lastName" json:"lastName"`
+	Roles      []string          `yaml:"roles" json:"roles"`
+	Attributes map[string]string `yaml:"attributes" json:"attributes"`
+}
+
+type scenarioAttribute struct {
+	Ref     string   `yaml:"ref" json:"ref"`
+	Name    string   `yaml:"name" json:"name"`
+	Type    string   `yaml:"type" json:"type"`
+	Options []string `yaml:"options" json:"options"`
+}
+
+type scenarioGroup struct {
+	Ref     string            `yaml:"ref" json:"ref"`
+	Name    string            `yaml:"name" json:"name"`
+	Filters []*scenarioFilter `yaml:"filters" json:"filters"`
+}
+
+type scenarioFilter struct {
+	Attribute string `yaml:"attribute" json:"attribute"`
+	Operator  string `yaml:"operator" json:"operator"`
+	Value     string `yaml:"value" json:"value"`
+}
+
+type scenarioCourse struct {
+	Ref     string          `yaml:"ref" json:"ref"`
+	Title   string          `yaml:"title" json:"title"`
+	Items   []*scenarioItem `yaml:"items" json:"items"`
+	Publish bool            `yaml:"publish" json:"publish"`
+}
+
+// scenarioItem is a learning item whose cards are read from a file relative
+// to the scenario, in the format of testdata/cards.json.
+type scenarioItem struct {
+	Ref         string `yaml:"ref" json:"ref"`
+	Type        string `yaml:"type" json:"type"`
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description" json:"description"`
+	Points      int    `yaml:"points" json:"points"`
+	Cards       string `yaml:"cards" json:"cards"`
+}
+
+type scenarioPlan struct {
+	Ref      string   `yaml:"ref" json:"ref"`
+	Name     string   `yaml:"name" json:"name"`
+	Courses  []string `yaml:"courses" json:"courses"`
+	Groups   []string `yaml:"groups" json:"groups"`
+	Activate bool     `yaml:"activate" json:"activate"`
+}
+
+// scenarioInvitation expects a learning plan to invite a user to a course.
+type scenarioInvitation struct {
+	User   string `yaml:"user" json:"user"`
+	Course string `yaml:"course" json:"course"`
+}
+
+func loadScenario(path string) (*scenario, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is valid YAML, the same decoder reads both.
+	sc := &scenario{dir: filepath.Dir(path)}
+	if err = yaml.Unmarshal(b, sc); err != nil {
+		return nil, fmt.Errorf("could not decode scenario %s: %w", path, err)
+	}
+
+	if err = sc.validate(); err != nil {
+		return nil, fmt.Errorf("invalid scenario %s: %w", path, err)
+	}
+
+	return sc, nil
+}
+
+// validate checks refs are unique and point at entities of the right kind,
+// before anything is provisioned.
+func (sc *scenario) validate() error {
+	var org string
+	kinds := map[string]string{}
+	sc.owners = map[string]string{}
+	declare := func(kind, ref string) error {
+		if ref == "" {
+			return fmt.Errorf("%s without ref", kind)
+		}
+
+		if _, ok := kinds[ref]; ok {
+			return fmt.Errorf("duplicate ref %s", ref)
+		}
+
+		kinds[ref], sc.owners[ref] = kind, org
+		return nil
+	}
+
+	use := func(kind, ref, by string) error {
+		if kinds[ref] != kind {
+			return fmt.Errorf("%s refers to unknown %s %s", by, kind, ref)
+		}
+
+		return nil
+	}
+
+	for _, o := range sc.Orgs {
+		org = o.Ref
+		if err := declare("org", o.Ref); err != nil {
+			return err
+		}
+
+		admin := false
+		for _, u := range o.Users {
+			if err := declare("user", u.Ref); err != nil {
+				return err
+			}
+			admin = admin || u.admin()
+		}
+
+		if !admin && (len(o.Attributes) > 0 || len(o.Groups) > 0 || len(o.Courses) > 0 || len(o.Plans) > 0) {
+			return fmt.Errorf("org %s has content but no ROLE_ADMIN user", o.Ref)
+		}
+
+		for _, a := range o.Attributes {
+			if err := declare("attribute", a.Ref); err != nil {
+				return err
+			}
+		}
+
+		for _, u := range o.Users {
+			for ref := range u.Attributes {
+				if err := use("attribute", ref, "user "+u.Ref); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, g := range o.Groups {
+			if err := declare("group", g.Ref); err != nil {
+				return err
+			}
+
+			for _, f := range g.Filters {
+				if err := use("attribute", f.Attribute, "group "+g.Ref); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, c := range o.Courses {
+			if err := declare("course", c.Ref); err != nil {
+				return err
+			}
+
+			for _, i := range c.Items {
+				if err := declare("item", i.Ref); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, p := range o.Plans {
+			if err := declare("plan", p.Ref); err != nil {
+				return err
+			}
+
+			for _, ref := range p.Courses {
+				if err := use("course", ref, "plan "+p.Ref); err != nil {
+					return err
+				}
+			}
+
+			for _, ref := range p.Groups {
+				if err := use("group", ref, "plan "+p.Ref); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, inv := range sc.Expect.Invitations {
+		if err := use("user", inv.User, "expected invitation"); err != nil {
+			return err
+		}
+
+		if err := use("course", inv.Course, "expected invitation"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// owner returns the ref of the org ref belongs to.
+func (sc *scenario) owner(ref string) string {
+	return sc.owners[ref]
+}
+
+func (u *scenarioUser) admin() bool {
+	for _, role := range u.Roles {
+		if role == "ROLE_ADMIN" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// provisioned holds what a scenario created, by ref. The credentials of an
+// org ref are those of its admin.
+type provisioned struct {
+	ids         map[string]string
+	orgs        map[string]*organization
+	users       map[string]*user
+	credentials map[string]userCredentials
+	groups      map[string]*learningGroup
+	courses     map[string]*course
+	items       map[string]*learningItem
+	cards       map[string][]*card
+	plans       map[string]*learningPlan
+}
+
+// id returns the id of the entity ref points at, empty if there is none.
+func (p *provisioned) id(ref string) string {
+	return p.ids[ref]
+}
+
+// provision creates the scenario through the gateway. On error, the orgs
+// created so far are still returned so they can be cleaned up.
+func (sc *scenario) provision(cli *apiClient, superAdmin userCredentials) (*provisioned, error) {
+	p := &provisioned{
+		ids:         map[string]string{},
+		orgs:        map[string]*organization{},
+		users:       map[string]*user{},
+		credentials: map[string]userCredentials{},
+		groups:      map[string]*learningGroup{},
+		courses:     map[string]*course{},
+		items:       map[string]*learningItem{},
+		cards:       map[string][]*card{},
+		plans:       map[string]*learningPlan{},
+	}
+
+	for _, o := range sc.Orgs {
+		if err := sc.provisionOrg(cli, superAdmin, o, p); err != nil {
+			return p, fmt.Errorf("org %s: %w", o.Ref, err)
+		}
+	}
+
+	return p, nil
+}
+
+func (sc *scenario) provisionOrg(cli *apiClient, superAdmin userCredentials, o *scenarioOrg, p *provisioned) error {
+	org, err := cli.createOrganization(createOrganizationRequest{Slug: o.Slug, Name: o.Name, Status: "ACTIVE"}, superAdmin)
+	if err != nil {
+		return err
+	}
+	p.orgs[o.Ref], p.ids[o.Ref] = org, org.ID
+
+	if superAdmin, err = superAdmin.in(org.ID); err != nil {
+		return err
+	}
+
+	var admin userCredentials
+	for _, u := range o.Users {
+		info, err := cli.createUser(createUserRequest{Email: u.Email, FirstName: u.FirstName, LastName: u.LastName, Roles: u.Roles}, superAdmin)
+		if err != nil {
+			return fmt.Errorf("user %s: %w", u.Ref, err)
+		}
+		p.users[u.Ref], p.ids[u.Ref] = info, info.ID
+
+		credentials, err := userLogin(u.Email, "password", org.ID, true)
+		if err != nil {
+			return fmt.Errorf("user %s: %w", u.Ref, err)
+		}
+		p.credentials[u.Ref] = credentials
+
+		if u.admin() && admin.accessToken == "" {
+			admin = credentials
+		}
+	}
+	p.credentials[o.Ref] = admin
+
+	if err = sc.provisionAttributes(cli, admin, org, o, p); err != nil {
+		return err
+	}
+
+	for _, g := range o.Groups {
+		req := createLearningGroupRequest{Name: g.Name}
+		for _, f := range g.Filters {
+			req.Attributes = append(req.Attributes, &attributeFilter{AttributeID: p.ids[f.Attribute], FilterOperator: f.Operator, Value: f.Value})
+		}
+
+		group, err := cli.createLearningGroup(req, admin)
+		if err != nil {
+			return fmt.Errorf("group %s: %w", g.Ref, err)
+		}
+		p.groups[g.Ref], p.ids[g.Ref] = group, group.ID
+	}
+
+	for _, c := range o.Courses {
+		if err = sc.provisionCourse(cli, admin, org, c, p); err != nil {
+			return fmt.Errorf("course %s: %w", c.Ref, err)
+		}
+	}
+
+	for _, plan := range o.Plans {
+		if err = sc.provisionPlan(cli, admin, plan, p); err != nil {
+			return fmt.Errorf("plan %s: %w", plan.Ref, err)
+		}
+	}
+
+	// Courses are published once in their plans, as setupCourse does.
+	for _, c := range o.Courses {
+		if !c.Publish {
+			continue
+		}
+
+		if p.courses[c.Ref], err = cli.activateCourse(p.ids[c.Ref], admin); err != nil {
+			return fmt.Errorf("course %s: %w", c.Ref, err)
+		}
+	}
+
+	for _, plan := range o.Plans {
+		if !plan.Activate {
+			continue
+		}
+
+		if p.plans[plan.Ref], err = cli.activateLearningPlan(p.ids[plan.Ref], admin); err != nil {
+			return fmt.Errorf("plan %s: %w", plan.Ref, err)
+		}
+	}
+
+	return nil
+}
+
+func (sc *scenario) provisionAttributes(cli *apiClient, admin userCredentials, org *organization, o *scenarioOrg, p *provisioned) error {
+	if len(o.Attributes) == 0 {
+		return nil
+	}
+
+	for _, a := range o.Attributes {
+		req := createOrgAttributeRequest{Name: a.Name, Organization: newIRI("organizations", org.ID), Type: a.Type}
+		for n, label := range a.Options {
+			req.AttributeOptions = append(req.AttributeOptions, &attributeOption{Label: label, SequenceOrder: n})
+		}
+
+		if err := cli.createOrgAttribute(req, admin); err != nil {
+			return fmt.Errorf("attribute %s: %w", a.Ref, err)
+		}
+	}
+
+	// Creating an attribute does not return it, its id comes from the list.
+	attributes, err := cli.orgAttributes(admin)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range o.Attributes {
+		for _, attr := range attributes {
+			if attr.Name == a.Name {
+				p.ids[a.Ref] = attr.ID
+			}
+		}
+
+		if p.ids[a.Ref] == "" {
+			return fmt.Errorf("attribute %s: not listed after creation", a.Ref)
+		}
+	}
+
+	for _, u := range o.Users {
+		// Sorted, for the cassettes to replay the requests in order.
+		for _, ref := range sortedKeys(u.Attributes) {
+			req := assignUserAttributesRequest{UserID: p.ids[u.Ref], AttributeID: p.ids[ref], Value: u.Attributes[ref]}
+			if err = cli.assignUserAttributes(req, admin); err != nil {
+				return fmt.Errorf("user %s: %w", u.Ref, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (sc *scenario) provisionCourse(cli *apiClient, admin userCredentials, org *organization, c *scenarioCourse, p *provisioned) error {
+	course, err := cli.createCourse(createCourseRequest{OrganizationId: org.ID, VersionName: c.Title, Title: c.Title}, admin)
+	if err != nil {
+		return err
+	}
+	p.courses[c.Ref], p.ids[c.Ref] = course, course.ID
+
+	for n, i := range c.Items {
+		item, err := cli.createLearningItem(createLearningItemRequest{
+			Course:        newIRI("courses", course.ID),
+			Type:          i.Type,
+			State:         "draft",
+			Name:          i.Name,
+			Description:   i.Description,
+			Points:        i.Points,
+			SequenceOrder: n,
+		}, admin)
+		if err != nil {
+			return fmt.Errorf("item %s: %w", i.Ref, err)
+		}
+		p.items[i.Ref], p.ids[i.Ref] = item, item.ID
+
+		if i.Cards == "" {
+			continue
+		}
+
+		if p.cards[i.Ref], err = cli.createCardsFromFile(item.ID, filepath.Join(sc.dir, i.Cards), admin); err != nil {
+			return fmt.Errorf("item %s: %w", i.Ref, err)
+		}
+	}
+
+	return nil
+}
+
+func (sc *scenario) provisionPlan(cli *apiClient, admin userCredentials, plan *scenarioPlan, p *provisioned) error {
+	lp, err := cli.createLearningPlan(createLearningPlanRequest{Name: plan.Name, ActivatedAt: time.Now().Format(time.RFC3339)}, admin)
+	if err != nil {
+		return err
+	}
+	p.plans[plan.Ref], p.ids[plan.Ref] = lp, lp.ID
+
+	if len(plan.Courses) > 0 {
+		req := addCoursesToLearningPlanRequest{}
+		for _, ref := range plan.Courses {
+			req.Courses = append(req.Courses, p.ids[ref])
+		}
+
+		if p.plans[plan.Ref], err = cli.addCoursesToLearningPlan(lp.ID, req, admin); err != nil {
+			return err
+		}
+	}
+
+	if len(plan.Groups) > 0 {
+		req := addGroupsToLearningPlanRequest{}
+		for _, ref := range plan.Groups {
+			req.LearningGroupIDs = append(req.LearningGroupIDs, p.ids[ref])
+		}
+
+		if p.plans[plan.Ref], err = cli.addGroupsToLearningPlan(lp.ID, req, admin); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
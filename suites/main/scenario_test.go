@@ -0,0 +1,20 @@
+package main_suite_test
+
+// provisionScenario provisions the scenario at path for the running test.
+func (s *harness) provisionScenario(path string) (*scenario, *provisioned) {
+	sc, err := loadScenario(path)
+	s.Require().Nil(err)
+
+	return sc, s.provision(sc)
+}
+
+// provision provisions sc for the running test, AfterTest deleting its orgs.
+func (s *harness) provision(sc *scenario) *provisioned {
+	p, err := sc.provision(s.apiClient, s.superAdmin)
+	for _, o := range p.orgs {
+		s.testOrgs = append(s.testOrgs, o)
+	}
+	s.Require().Nil(err)
+
+	return p
+}
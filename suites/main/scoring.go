@@ -0,0 +1,193 @@
+package main_suite_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	choiceSelectOne  = "selectone"
+	choiceSelectMany = "selectall"
+
+	blockMultipleChoice = "multipleChoice"
+	blockTrueFalse      = "trueFalse"
+	blockFreeResponse   = "freeResponse"
+)
+
+type richText struct {
+	Type     string `json:"type"`
+	Children []struct {
+		Text string `json:"text"`
+	} `json:"children"`
+}
+
+type quizOption struct {
+	ID         string     `json:"id"`
+	IsCorrect  bool       `json:"isCorrect"`
+	OptionText []richText `json:"optionText"`
+}
+
+func (o quizOption) text() string {
+	var b strings.Builder
+	for _, t := range o.OptionText {
+		for _, c := range t.Children {
+			b.WriteString(c.Text)
+		}
+	}
+
+	return b.String()
+}
+
+type quizBlock struct {
+	ID                        string       `json:"id"`
+	Type                      string       `json:"type"`
+	MultipleChoiceType        string       `json:"multipleChoiceType"`
+	Options                   []quizOption `json:"options"`
+	TextResponseRequired      bool         `json:"textResponseRequired"`
+	TextResponseMinimumLength int          `json:"textResponseMinimumLength"`
+}
+
+// quizCard is the typed form of a card definition from testdata.
+type quizCard struct {
+	Type            string `json:"type"`
+	Title           string `json:"title"`
+	SequenceOrder   int    `json:"sequenceOrder"`
+	ConfidenceCheck bool   `json:"confidenceCheck"`
+	JSON            struct {
+		TemplateType  *string     `json:"templateType"`
+		ContentBlocks []quizBlock `json:"contentBlocks"`
+	} `json:"json"`
+}
+
+// question returns the scored block of the card, if any.
+func (c *quizCard) question() *quizBlock {
+	for i, b := range c.JSON.ContentBlocks {
+		switch b.Type {
+		case blockMultipleChoice, blockTrueFalse, blockFreeResponse:
+			return &c.JSON.ContentBlocks[i]
+		}
+	}
+
+	return nil
+}
+
+func loadQuizCards(path string) ([]*quizCard, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var definitions struct {
+		Cards []*quizCard `json:"cards"`
+	}
+	if err = json.Unmarshal(b, &definitions); err != nil {
+		return nil, err
+	}
+
+	return definitions.Cards, nil
+}
+
+// scoringOracle derives the score the platform should give an answer from
+// the card definitions the course was authored with. Its rules are the fake
+// platform's; the real platform does not document how it scores, so checks
+// built on the oracle run against the fake only.
+type scoringOracle struct {
+	cards map[string]*quizCard
+}
+
+// newScoringOracle pairs definitions with the cards created from them, in
+// the order createCardsFromFile returned them.
+func newScoringOracle(definitions []*quizCard, created []*card) (*scoringOracle, error) {
+	if len(definitions) != len(created) {
+		return nil, fmt.Errorf("%d card definitions for %d cards", len(definitions), len(created))
+	}
+
+	o := &scoringOracle{cards: map[string]*quizCard{}}
+	for i, c := range created {
+		o.cards[c.ID] = definitions[i]
+	}
+
+	return o, nil
+}
+
+func (o *scoringOracle) definition(cardID string) *quizCard {
+	return o.cards[cardID]
+}
+
+func sameAnswers(a, b []string) bool {
+	a, b = append([]string(nil), a...), append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+
+	return strings.Join(a, "\x00") == strings.Join(b, "\x00")
+}
+
+// score returns 1 for a correct answer and 0 otherwise. Free responses are
+// only worth a point once an admin approved them.
+func (o *scoringOracle) score(cardID string, answer []string, approved bool) int32 {
+	def := o.cards[cardID]
+	if def == nil {
+		return 0
+	}
+
+	q := def.question()
+	if q == nil {
+		return 0
+	}
+
+	if q.Type == blockFreeResponse {
+		if approved && o.validFreeResponse(q, answer) {
+			return 1
+		}
+
+		return 0
+	}
+
+	var correct []string
+	for _, opt := range q.Options {
+		if opt.IsCorrect {
+			correct = append(correct, opt.text())
+		}
+	}
+
+	if q.MultipleChoiceType != choiceSelectMany && len(answer) != 1 {
+		return 0
+	}
+
+	if sameAnswers(correct, answer) {
+		return 1
+	}
+
+	return 0
+}
+
+func (o *scoringOracle) validFreeResponse(q *quizBlock, answer []string) bool {
+	text := strings.TrimSpace(strings.Join(answer, ""))
+	if !q.TextResponseRequired {
+		return true
+	}
+
+	return len(text) >= max(q.TextResponseMinimumLength, 1)
+}
+
+// confidence returns the confidence the platform should keep: it is only
+// recorded on cards with a confidence check.
+func (o *scoringOracle) confidence(cardID string, confidence int32) int32 {
+	if def := o.cards[cardID]; def != nil && def.ConfidenceCheck {
+		return confidence
+	}
+
+	return 0
+}
+
+func (o *scoringOracle) learningItemTotal(cards []*cardEnrollment) int32 {
+	var total int32
+	for _, c := range cards {
+		total += o.score(c.CardId, c.Answer, c.Approved)
+	}
+
+	return total
+}
@@ -0,0 +1,114 @@
+package main_suite_test
+
+import (
+	"time"
+)
+
+// assertScores checks the scores and totals the platform computed for a
+// stored enrollment against the scoring oracle, against the fake platform
+// only.
+func (s *courseSuite) assertScores(stored *courseEnrollment) {
+	if !config.fakePlatform {
+		return
+	}
+
+	var courseTotal int32
+	for _, li := range stored.LearningItemEnrollments {
+		for _, c := range li.CardEnrollments {
+			s.Assert().Equal(s.scoring.score(c.CardId, c.Answer, c.Approved), c.Score, "score of card %s", c.CardId)
+			s.Assert().Equal(s.scoring.confidence(c.CardId, c.Confidence), c.Confidence, "confidence of card %s", c.CardId)
+		}
+
+		total := s.scoring.learningItemTotal(li.CardEnrollments)
+		s.Assert().Equal(total, li.TotalPoints, "total points of learning item %s", li.LearningItemId)
+		courseTotal += total
+	}
+
+	s.Assert().Equal(courseTotal, stored.TotalPoints)
+}
+
+func (s *EnrollmentSuite) TestQuizScoring() {
+	s.requireFakeRules()
+
+	for _, tc := range []struct {
+		name    string
+		answers map[int][]string
+		total   int32
+		// approvedTotal is the total once the free response is approved.
+		approvedTotal int32
+	}{
+		{
+			name: "correct",
+			answers: map[int][]string{
+				1: {"Europe"},
+				2: {"France"},
+				3: {"True"},
+				4: {"Japan, for the food."},
+			},
+			total:         3,
+			approvedTotal: 4,
+		},
+		{
+			name: "incorrect",
+			answers: map[int][]string{
+				1: {"Africa"},
+				2: {"France", "Netherlands"},
+				3: {"False"},
+				4: {" "},
+			},
+		},
+		{
+			name: "partial",
+			answers: map[int][]string{
+				1: {"Europe", "Africa"},
+				2: {"Netherlands"},
+				3: {"True"},
+			},
+			total:         1,
+			approvedTotal: 1,
+		},
+	} {
+		s.Run(tc.name, func() {
+			_, _, clone := s.enrolledLearner("scoring_" + tc.name)
+
+			q := s.quiz.ID
+			sim := s.syncSimulator(clone, deviceAID)
+			for i, answer := range tc.answers {
+				sim.apply(deviceAnswer{deviceAID, q, s.quizCards[i].ID, answer, time.Duration(i) * time.Minute})
+			}
+
+			// Confidence is sent for every card but only kept where it is checked.
+			for _, c := range sim.devices[deviceAID].learningItem(q).CardEnrollments {
+				c.Confidence = 1
+			}
+			s.syncDevices(sim, deviceAID)
+
+			stored, err := s.enrollments.courseEnrollment(clone.CourseEnrollmentID)
+			s.Require().Nil(err)
+			s.assertScores(stored)
+			s.Assert().Equal(tc.total, stored.TotalPoints)
+
+			state := &virtualDevice{enrollments: stored.LearningItemEnrollments}
+			s.Assert().Zero(state.card(q, s.quizCards[0].ID).Confidence)
+			s.Assert().Equal(int32(1), state.card(q, s.quizCards[1].ID).Confidence)
+
+			freeResponse := state.card(q, s.quizCards[4].ID)
+			s.Require().NotNil(freeResponse)
+			s.Assert().False(freeResponse.Approved)
+			if _, answered := tc.answers[4]; !answered {
+				return
+			}
+
+			// The gateway has no endpoint approving an answer, the fake's
+			// card enrollment is approved in place.
+			s.Require().Nil(fake.approveCardEnrollment(freeResponse.CardEnrollmentId))
+
+			stored, err = s.enrollments.courseEnrollment(clone.CourseEnrollmentID)
+			s.Require().Nil(err)
+			state = &virtualDevice{enrollments: stored.LearningItemEnrollments}
+			s.Assert().True(state.card(q, s.quizCards[4].ID).Approved)
+			s.assertScores(stored)
+			s.Assert().Equal(tc.approvedTotal, stored.TotalPoints)
+		})
+	}
+}
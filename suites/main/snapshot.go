@@ -0,0 +1,148 @@
+package main_suite_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	dbSnapshotReport = "report"
+	dbSnapshotFail   = "fail"
+)
+
+// snapshotTable is a table captured around each test, with the column
+// holding the org a row belongs to.
+type snapshotTable struct {
+	table     table
+	orgColumn string
+}
+
+var defaultSnapshotTables = []snapshotTable{
+	{organizationTable, "id"},
+	{userTable, "organization_id"},
+	{attributeTable, "organization_id"},
+	{learningGroupTable, "organization_id"},
+	{courseTable, "organization_id"},
+	{learningPlanTable, "organization_id"},
+	{invitationTable, "organization_id"},
+}
+
+// parseSnapshotTables reads DB_SNAPSHOT_TABLES, a comma separated list of
+// schema.table[:org_column] entries.
+func parseSnapshotTables(s string) ([]snapshotTable, error) {
+	if s == "" {
+		return defaultSnapshotTables, nil
+	}
+
+	var tables []snapshotTable
+	for _, entry := range strings.Split(s, ",") {
+		name, orgColumn, _ := strings.Cut(strings.TrimSpace(entry), ":")
+		schema, tableName, ok := strings.Cut(name, ".")
+		if !ok {
+			return nil, fmt.Errorf("invalid snapshot table %q, expected schema.table", entry)
+		}
+
+		if orgColumn == "" {
+			orgColumn = "organization_id"
+		}
+		tables = append(tables, snapshotTable{table{schema, tableName}, orgColumn})
+	}
+
+	return tables, nil
+}
+
+type snapshotRow struct {
+	orgID    string
+	checksum string
+}
+
+// dbSnapshot holds a checksum of every row of the captured tables, by id.
+type dbSnapshot map[table]map[string]snapshotRow
+
+func rowChecksum(row dbRow) string {
+	columns := make([]string, 0, len(row))
+	for c := range row {
+		columns = append(columns, c)
+	}
+	sort.Strings(columns)
+
+	h := sha256.New()
+	for _, c := range columns {
+		fmt.Fprintf(h, "%s=%v\x00", c, row[c])
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// snapshot captures the rows of every org but exceptOrgIDs, and the rows of
+// no org. The orgs of other suites and other tenants are captured too, so a
+// write into any of them shows in the diff.
+func (q *dbQuery) snapshot(tables []snapshotTable, exceptOrgIDs ...string) (dbSnapshot, error) {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(exceptOrgIDs)), ", ")
+	args := make([]any, len(exceptOrgIDs))
+	for i, id := range exceptOrgIDs {
+		args[i] = id
+	}
+
+	snap := dbSnapshot{}
+	for _, t := range tables {
+		where := "1 = 1"
+		if len(exceptOrgIDs) > 0 {
+			where = fmt.Sprintf("%s not in (%s) or %s is null", t.orgColumn, placeholders, t.orgColumn)
+		}
+
+		rows, err := q.rows(t.table, where, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		snap[t.table] = map[string]snapshotRow{}
+		for _, row := range rows {
+			snap[t.table][row.str("id")] = snapshotRow{orgID: row.str(t.orgColumn), checksum: rowChecksum(row)}
+		}
+	}
+
+	return snap, nil
+}
+
+type rowChange struct {
+	table  table
+	id     string
+	orgID  string
+	change string
+}
+
+func (c rowChange) String() string {
+	return fmt.Sprintf("%s %s.%s (org %s)", c.change, c.table, c.id, c.orgID)
+}
+
+// diff returns the rows inserted, updated or deleted since before.
+func (after dbSnapshot) diff(before dbSnapshot) []rowChange {
+	var changes []rowChange
+	for t, rows := range after {
+		for id, row := range rows {
+			prev, ok := before[t][id]
+			switch {
+			case !ok:
+				changes = append(changes, rowChange{t, id, row.orgID, "insert"})
+			case prev.checksum != row.checksum:
+				changes = append(changes, rowChange{t, id, row.orgID, "update"})
+			}
+		}
+
+		for id, prev := range before[t] {
+			if _, ok := rows[id]; !ok {
+				changes = append(changes, rowChange{t, id, prev.orgID, "delete"})
+			}
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].String() < changes[j].String()
+	})
+
+	return changes
+}
@@ -0,0 +1,155 @@
+package main_suite_test
+
+import (
+	"sync"
+	"time"
+)
+
+func (s *courseSuite) storedEnrollment(courseEnrollmentID string) *courseEnrollment {
+	stored, err := s.enrollments.courseEnrollment(courseEnrollmentID)
+	s.Require().Nil(err)
+
+	return stored
+}
+
+// assertSameEnrollment checks nothing, points or completion included,
+// changed between two reads of the same enrollment.
+func (s *courseSuite) assertSameEnrollment(expected, actual *courseEnrollment) {
+	s.Assert().Equal(expected.TotalPoints, actual.TotalPoints)
+	s.Assert().Equal(expected.Progress, actual.Progress)
+	s.assertSameTime(expected.CompletedAt, actual.CompletedAt, "course completedAt")
+	s.Assert().Equal(len(expected.LearningItemEnrollments), len(actual.LearningItemEnrollments))
+
+	for _, li := range expected.LearningItemEnrollments {
+		a := (&virtualDevice{enrollments: actual.LearningItemEnrollments}).learningItem(li.LearningItemId)
+		if !s.Assert().NotNil(a, "learning item %s", li.LearningItemId) {
+			continue
+		}
+
+		s.Assert().Equal(li.TotalPoints, a.TotalPoints, "total points of learning item %s", li.LearningItemId)
+		s.Assert().Equal(li.Progress, a.Progress, "progress of learning item %s", li.LearningItemId)
+		s.assertSameTime(li.CompletedAt, a.CompletedAt, "completedAt of learning item "+li.LearningItemId)
+	}
+
+	s.assertStoredEnrollment(actual.CourseEnrollmentID, expected.LearningItemEnrollments)
+}
+
+// snapshot copies the pending sync request of device.
+func (s *courseSuite) snapshot(device *virtualDevice) syncEnrollmentRequest {
+	req, err := device.snapshot()
+	s.Require().Nil(err)
+
+	return req
+}
+
+// completeQuiz answers every quiz card correctly on a device.
+func (s *courseSuite) completeQuiz(sim *syncSimulator, deviceID string, after time.Duration) {
+	answers := map[int][]string{1: {"Europe"}, 2: {"France"}, 3: {"True"}, 4: {"Japan, for the food."}}
+	for i, c := range s.quizCards {
+		sim.apply(deviceAnswer{deviceID, s.quiz.ID, c.ID, answers[i], after + time.Duration(i)*time.Second})
+	}
+}
+
+func (s *EnrollmentSuite) TestSyncRepeatedIsIdempotent() {
+	s.requireFakeRules()
+
+	_, _, clone := s.enrolledLearner("replay_repeated")
+
+	sim := s.syncSimulator(clone, deviceAID)
+	s.completeQuiz(sim, deviceAID, time.Minute)
+	req := s.snapshot(sim.devices[deviceAID])
+
+	s.syncDevices(sim, deviceAID)
+	first := s.storedEnrollment(clone.CourseEnrollmentID)
+	s.assertScores(first)
+
+	for i := 0; i < 5; i += 1 {
+		results := s.sync(req, s.orgAdmin)
+		for _, r := range results {
+			s.Assert().True(r.Success, r.Message)
+		}
+
+		s.assertSameEnrollment(first, s.storedEnrollment(clone.CourseEnrollmentID))
+	}
+
+	s.Assert().Empty(s.duplicateEnrollments(clone))
+}
+
+func (s *EnrollmentSuite) TestSyncConcurrentRetries() {
+	s.requireFakeRules()
+
+	_, _, clone := s.enrolledLearner("replay_concurrent")
+
+	sim := s.syncSimulator(clone, deviceAID)
+	s.completeQuiz(sim, deviceAID, time.Minute)
+	req := s.snapshot(sim.devices[deviceAID])
+
+	const retries = 8
+	errs := make([]error, retries)
+	results := make([][]*syncEnrollmentsResult, retries)
+
+	var wg sync.WaitGroup
+	for i := 0; i < retries; i += 1 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = s.apiClient.syncEnrollments(req, s.orgAdmin)
+		}()
+	}
+	wg.Wait()
+
+	for i := range errs {
+		s.Require().Nil(errs[i])
+		for _, r := range results[i] {
+			s.Assert().True(r.Success, r.Message)
+		}
+	}
+
+	stored := s.assertStoredEnrollment(clone.CourseEnrollmentID, req.LearningItemEnrollments)
+	s.assertScores(stored)
+	s.Assert().Empty(s.duplicateEnrollments(clone))
+
+	// A late retry after the storm changes nothing either.
+	s.sync(req, s.orgAdmin)
+	s.assertSameEnrollment(stored, s.storedEnrollment(clone.CourseEnrollmentID))
+}
+
+func (s *EnrollmentSuite) TestSyncStaleSnapshotReplay() {
+	s.requireFakeRules()
+
+	_, _, clone := s.enrolledLearner("replay_stale")
+
+	sim := s.syncSimulator(clone, deviceAID)
+	sim.apply(deviceAnswer{deviceAID, s.quiz.ID, s.quizCards[1].ID, []string{"Africa"}, time.Minute})
+	older := s.snapshot(sim.devices[deviceAID])
+
+	// The newer snapshot completes the quiz.
+	s.completeQuiz(sim, deviceAID, 2*time.Minute)
+	newer := s.snapshot(sim.devices[deviceAID])
+
+	for _, order := range [][]syncEnrollmentRequest{
+		{newer, older},
+		{older, newer, older},
+	} {
+		for _, req := range order {
+			s.sync(req, s.orgAdmin)
+		}
+
+		stored := s.assertStoredEnrollment(clone.CourseEnrollmentID, newer.LearningItemEnrollments)
+		s.assertScores(stored)
+	}
+
+	completed := s.storedEnrollment(clone.CourseEnrollmentID)
+	quiz := storedLearningItem(completed.LearningItemEnrollments, sim.devices[deviceAID].learningItem(s.quiz.ID))
+	s.Require().NotNil(quiz)
+	s.Require().NotEmpty(quiz.CompletedAt)
+
+	// Replaying the stale snapshot is handled the same way every time, and
+	// neither counts the quiz twice nor moves its completion.
+	first := s.sync(older, s.orgAdmin)
+	for i := 0; i < 3; i += 1 {
+		s.Assert().Equal(first, s.sync(older, s.orgAdmin))
+	}
+
+	s.assertSameEnrollment(completed, s.storedEnrollment(clone.CourseEnrollmentID))
+}
@@ -0,0 +1,121 @@
+package main_suite_test
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	deviceBID = "device-b-id"
+	deviceCID = "device-c-id"
+)
+
+// syncSimulator starts a simulator with a virtual device per id, each
+// holding its own copy of clone.
+func (s *courseSuite) syncSimulator(clone *cloneEnrollmentResponse, deviceIDs ...string) *syncSimulator {
+	sim, err := newSyncSimulator(clone, deviceIDs...)
+	s.Require().Nil(err)
+
+	return sim
+}
+
+// expectedEnrollments returns the enrollments sim should converge to.
+func (s *courseSuite) expectedEnrollments(sim *syncSimulator) []*learningItemEnrollment {
+	expected, err := sim.expected()
+	s.Require().Nil(err)
+
+	return expected
+}
+
+// syncDevices syncs each device in order and requires every item to succeed.
+func (s *courseSuite) syncDevices(sim *syncSimulator, order ...string) {
+	for _, deviceID := range order {
+		results, err := s.apiClient.syncEnrollments(sim.devices[deviceID].syncRequest(), s.orgAdmin)
+		s.Require().Nil(err)
+
+		for _, r := range results {
+			s.Require().True(r.Success, r.Message)
+		}
+		sim.synced(deviceID)
+	}
+}
+
+// assertMergedEnrollment checks that the server kept, for every answered
+// card, the answer with the latest UpdatedAt whatever the sync order was.
+func (s *courseSuite) assertMergedEnrollment(clone *cloneEnrollmentResponse, sim *syncSimulator) {
+	s.assertStoredEnrollment(clone.CourseEnrollmentID, s.expectedEnrollments(sim))
+}
+
+// assertDuplicates checks that the first device to sync owns the enrollment
+// and every other device left exactly one duplicate of it.
+func (s *courseSuite) assertDuplicates(clone *cloneEnrollmentResponse, order []string) {
+	duplicates := s.duplicateEnrollments(clone)
+	deviceIDs := make([]string, len(duplicates))
+	for i, d := range duplicates {
+		s.Assert().Equal(s.quiz.ID, d.LearningItemId)
+		deviceIDs[i] = d.DeviceId
+	}
+
+	s.Assert().ElementsMatch(order[1:], deviceIDs)
+}
+
+func (s *EnrollmentSuite) TestSyncConflictsAcrossDevices() {
+	s.requireFakeRules()
+
+	devices := []string{deviceAID, deviceBID, deviceCID}
+	for _, order := range permutations(devices) {
+		s.Run(strings.Join(order, ","), func() {
+			_, _, clone := s.enrolledLearner("sync_" + strings.Join(order, "_"))
+
+			q := s.quiz.ID
+			sim := s.syncSimulator(clone, devices...)
+			sim.apply(
+				deviceAnswer{deviceAID, q, s.quizCards[1].ID, []string{"Europe"}, time.Minute},
+				deviceAnswer{deviceCID, q, s.quizCards[3].ID, []string{"False"}, 90 * time.Second},
+				deviceAnswer{deviceBID, q, s.quizCards[1].ID, []string{"Africa"}, 2 * time.Minute},
+				deviceAnswer{deviceCID, q, s.quizCards[2].ID, []string{"France"}, 3 * time.Minute},
+				deviceAnswer{deviceAID, q, s.quizCards[2].ID, []string{"France", "Netherlands"}, 4 * time.Minute},
+				deviceAnswer{deviceBID, q, s.quizCards[3].ID, []string{"True"}, 5 * time.Minute},
+			)
+
+			s.syncDevices(sim, order...)
+			s.assertMergedEnrollment(clone, sim)
+			s.assertDuplicates(clone, order)
+		})
+	}
+}
+
+func (s *EnrollmentSuite) TestSyncSameDeviceOverrides() {
+	s.requireFakeRules()
+
+	_, _, clone := s.enrolledLearner("sync_same_device")
+
+	q := s.quiz.ID
+	sim := s.syncSimulator(clone, deviceAID)
+	sim.apply(deviceAnswer{deviceAID, q, s.quizCards[2].ID, []string{"France", "Netherlands"}, time.Minute})
+	s.syncDevices(sim, deviceAID)
+
+	sim.apply(deviceAnswer{deviceAID, q, s.quizCards[2].ID, []string{"Argentina", "France"}, 2 * time.Minute})
+	s.syncDevices(sim, deviceAID)
+
+	s.assertMergedEnrollment(clone, sim)
+	s.assertDuplicates(clone, []string{deviceAID})
+}
+
+func (s *EnrollmentSuite) TestSyncStaleDeviceDoesNotOverride() {
+	s.requireFakeRules()
+
+	_, _, clone := s.enrolledLearner("sync_stale_device")
+
+	// Device B answered later but reconnects first; A's older answer must lose.
+	q := s.quiz.ID
+	sim := s.syncSimulator(clone, deviceAID, deviceBID)
+	sim.apply(
+		deviceAnswer{deviceAID, q, s.quizCards[1].ID, []string{"Africa"}, time.Minute},
+		deviceAnswer{deviceBID, q, s.quizCards[1].ID, []string{"Europe"}, 10 * time.Minute},
+	)
+
+	s.syncDevices(sim, deviceBID, deviceAID)
+	s.assertMergedEnrollment(clone, sim)
+	s.assertDuplicates(clone, []string{deviceBID, deviceAID})
+}
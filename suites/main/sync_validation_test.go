@@ -0,0 +1,202 @@
+package main_suite_test
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// sync sends req and returns its results keyed by learning item enrollment.
+func (s *courseSuite) sync(req syncEnrollmentRequest, credentials userCredentials) map[string]*syncEnrollmentsResult {
+	results, err := s.apiClient.syncEnrollments(req, credentials)
+	s.Require().Nil(err)
+	s.Require().Equal(len(req.LearningItemEnrollments), len(results))
+
+	byID := map[string]*syncEnrollmentsResult{}
+	for _, r := range results {
+		byID[r.LearningItemEnrollmentID] = r
+	}
+
+	return byID
+}
+
+func (s *courseSuite) assertSyncAccepted(results map[string]*syncEnrollmentsResult, learningItemEnrollmentID string) {
+	r, ok := results[learningItemEnrollmentID]
+	if !s.Assert().True(ok, "no result for %s", learningItemEnrollmentID) {
+		return
+	}
+
+	s.Assert().True(r.Success, r.Message)
+}
+
+func (s *courseSuite) assertSyncRejected(results map[string]*syncEnrollmentsResult, learningItemEnrollmentID, msg string) {
+	r, ok := results[learningItemEnrollmentID]
+	if !s.Assert().True(ok, "no result for %s", learningItemEnrollmentID) {
+		return
+	}
+
+	s.Assert().False(r.Success)
+	s.Assert().Contains(r.Message, msg)
+}
+
+// answeredQuiz returns a device of the learner with the first quiz card answered.
+func (s *courseSuite) answeredQuiz(clone *cloneEnrollmentResponse) (*virtualDevice, *learningItemEnrollment) {
+	sim := s.syncSimulator(clone, deviceAID)
+	sim.apply(deviceAnswer{deviceAID, s.quiz.ID, s.quizCards[1].ID, []string{"Europe"}, time.Minute})
+
+	device := sim.devices[deviceAID]
+	return device, device.learningItem(s.quiz.ID)
+}
+
+// deviceCard returns the card enrollment of cardID held by device.
+func (s *courseSuite) deviceCard(device *virtualDevice, learningItemID, cardID string) *cardEnrollment {
+	c := device.card(learningItemID, cardID)
+	s.Require().NotNil(c, "card %s", cardID)
+
+	return c
+}
+
+func (s *EnrollmentSuite) TestSyncUnknownEnrollment() {
+	_, _, clone := s.enrolledLearner("sync_unknown")
+	_, li := s.answeredQuiz(clone)
+	li.LearningItemEnrollmentId = "not-found"
+
+	results := s.sync(syncEnrollmentRequest{LearningItemEnrollments: []*learningItemEnrollment{li}}, s.orgAdmin)
+	s.assertSyncRejected(results, "not-found", syncErrEnrollmentNotFound)
+}
+
+func (s *EnrollmentSuite) TestSyncEnrollmentOfAnotherUser() {
+	_, _, clone := s.enrolledLearner("sync_owner")
+	_, otherLearner, _ := s.enrolledLearner("sync_not_owner")
+	device, li := s.answeredQuiz(clone)
+
+	results := s.sync(device.syncRequest(), otherLearner)
+	s.assertSyncRejected(results, li.LearningItemEnrollmentId, syncErrNotOwner)
+
+	// Enrollments of another org are not even visible.
+	results = s.sync(device.syncRequest(), s.otherAdmin)
+	s.assertSyncRejected(results, li.LearningItemEnrollmentId, syncErrEnrollmentNotFound)
+
+	s.assertStoredEnrollment(clone.CourseEnrollmentID, clone.LearningItemEnrollments)
+}
+
+func (s *EnrollmentSuite) TestSyncCardNotInLearningItem() {
+	_, _, clone := s.enrolledLearner("sync_foreign_card")
+	device, li := s.answeredQuiz(clone)
+
+	// A lesson card moved into the quiz enrollment.
+	c := s.deviceCard(device, s.quiz.ID, s.quizCards[1].ID)
+	c.CardId = s.lessonCards[1].ID
+
+	results := s.sync(device.syncRequest(), s.orgAdmin)
+	s.assertSyncRejected(results, li.LearningItemEnrollmentId, syncErrCardNotFound)
+
+	c.CardId = "not-found"
+	results = s.sync(device.syncRequest(), s.orgAdmin)
+	s.assertSyncRejected(results, li.LearningItemEnrollmentId, syncErrCardNotFound)
+}
+
+func (s *EnrollmentSuite) TestSyncAnswerOnNonQuizCard() {
+	_, _, clone := s.enrolledLearner("sync_non_quiz")
+	device, li := s.answeredQuiz(clone)
+
+	// The first quiz card is a title card.
+	device.answer(s.quiz.ID, s.quizCards[0].ID, []string{"Europe"}, time.Now().Add(-time.Minute))
+
+	results := s.sync(device.syncRequest(), s.orgAdmin)
+	s.assertSyncRejected(results, li.LearningItemEnrollmentId, syncErrNotQuizCard)
+}
+
+func (s *EnrollmentSuite) TestSyncInvalidTimestamps() {
+	_, _, clone := s.enrolledLearner("sync_timestamps")
+
+	// Cases run in a fixed order for the cassettes to replay.
+	invalid := map[string]func(li *learningItemEnrollment, c *cardEnrollment){
+		"card updatedAt":          func(_ *learningItemEnrollment, c *cardEnrollment) { c.UpdatedAt = "2024/09/10 10:00:00" },
+		"card completedAt":        func(_ *learningItemEnrollment, c *cardEnrollment) { c.CompletedAt = "yesterday" },
+		"learning item startedAt": func(li *learningItemEnrollment, _ *cardEnrollment) { li.StartedAt = "1725962400" },
+		"missing timezone":        func(li *learningItemEnrollment, _ *cardEnrollment) { li.UpdatedAt = "2024-09-10T10:00:00.123" },
+	}
+	for _, name := range sortedKeys(invalid) {
+		set := invalid[name]
+		s.Run(name, func() {
+			device, li := s.answeredQuiz(clone)
+			set(li, s.deviceCard(device, s.quiz.ID, s.quizCards[1].ID))
+
+			results := s.sync(device.syncRequest(), s.orgAdmin)
+			s.assertSyncRejected(results, li.LearningItemEnrollmentId, syncErrInvalidTimestamp)
+		})
+	}
+
+	layouts := map[string]string{
+		"RFC3339":     time.RFC3339,
+		"RFC3339Nano": time.RFC3339Nano,
+		"UTC":         "2006-01-02T15:04:05.999Z",
+	}
+	for _, name := range sortedKeys(layouts) {
+		layout := layouts[name]
+		s.Run(name, func() {
+			device, li := s.answeredQuiz(clone)
+			at := time.Now().UTC().Add(-time.Minute)
+			li.UpdatedAt = at.Format(layout)
+			s.deviceCard(device, s.quiz.ID, s.quizCards[1].ID).UpdatedAt = at.Format(layout)
+
+			results := s.sync(device.syncRequest(), s.orgAdmin)
+			s.assertSyncAccepted(results, li.LearningItemEnrollmentId)
+		})
+	}
+}
+
+func (s *EnrollmentSuite) TestSyncFutureTimestamp() {
+	_, _, clone := s.enrolledLearner("sync_future")
+	device, li := s.answeredQuiz(clone)
+	device.answer(s.quiz.ID, s.quizCards[1].ID, []string{"Africa"}, time.Now().Add(24*time.Hour))
+
+	results := s.sync(device.syncRequest(), s.orgAdmin)
+	s.assertSyncRejected(results, li.LearningItemEnrollmentId, syncErrFutureTimestamp)
+
+	s.assertStoredEnrollment(clone.CourseEnrollmentID, clone.LearningItemEnrollments)
+}
+
+func (s *EnrollmentSuite) TestSyncOversizedPayload() {
+	_, _, clone := s.enrolledLearner("sync_oversized")
+	device, _ := s.answeredQuiz(clone)
+	s.deviceCard(device, s.quiz.ID, s.quizCards[4].ID).Answer = []string{strings.Repeat("a", 10<<20)}
+
+	_, err := s.apiClient.syncEnrollments(device.syncRequest(), s.orgAdmin)
+	s.httpCode(err, http.StatusRequestEntityTooLarge)
+
+	// Batches are limited in size too, not only in bytes.
+	lis := make([]*learningItemEnrollment, 1000)
+	for i := range lis {
+		lis[i] = device.learningItem(s.lesson.ID)
+	}
+
+	_, err = s.apiClient.syncEnrollments(syncEnrollmentRequest{LearningItemEnrollments: lis}, s.orgAdmin)
+	s.httpCode(err, http.StatusRequestEntityTooLarge)
+}
+
+func (s *EnrollmentSuite) TestSyncPartialBatch() {
+	_, _, clone := s.enrolledLearner("sync_partial")
+	device, quiz := s.answeredQuiz(clone)
+
+	lesson := device.learningItem(s.lesson.ID)
+	device.answer(s.lesson.ID, s.lessonCards[1].ID, nil, time.Now().Add(-time.Minute))
+	s.deviceCard(device, s.lesson.ID, s.lessonCards[0].ID).UpdatedAt = "not-a-timestamp"
+
+	unknown := *quiz
+	unknown.LearningItemEnrollmentId = "not-found"
+
+	req := device.syncRequest()
+	req.LearningItemEnrollments = append(req.LearningItemEnrollments, &unknown)
+
+	results := s.sync(req, s.orgAdmin)
+	s.assertSyncAccepted(results, quiz.LearningItemEnrollmentId)
+	s.assertSyncRejected(results, lesson.LearningItemEnrollmentId, syncErrInvalidTimestamp)
+	s.assertSyncRejected(results, "not-found", syncErrEnrollmentNotFound)
+
+	// Only the valid item was persisted.
+	written, err := device.written(s.quiz.ID)
+	s.Require().Nil(err)
+	s.assertStoredEnrollment(clone.CourseEnrollmentID, []*learningItemEnrollment{written})
+}
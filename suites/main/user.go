@@ -13,6 +13,13 @@ func (c *userCredentials) switchOrg(orgID string) (err error) {
 	return nil
 }
 
+// in returns a copy of the credentials switched to orgID, leaving c alone for
+// the tests sharing it.
+func (c userCredentials) in(orgID string) (userCredentials, error) {
+	err := c.switchOrg(orgID)
+	return c, err
+}
+
 func userLogin(username, password, orgID string, acceptTerms bool) (c userCredentials, err error) {
 	if c.accessToken, err = login(orgID, username, password); err != nil {
 		return c, err
@@ -0,0 +1,233 @@
+package main_suite_test
+
+import (
+	"fmt"
+)
+
+// worldBuilder composes orgs and their content in Go, the way a scenario file
+// does, with defaults for everything but the refs:
+//
+//	b := newWorldBuilder()
+//	school := b.org("school")
+//	school.admin("admin")
+//	school.learner("blue").attribute("color", "Blue")
+//	school.attribute("color", "Red", "Green", "Blue")
+//	school.group("blues").filter("color", "EQ", "Blue")
+//	school.course("geography").lesson("intro").quiz("test")
+//	school.plan("semester").courses("geography").groups("blues")
+//	w := s.buildWorld(b)
+//
+// Courses are published and plans activated unless told otherwise. Slugs and
+// emails derive from the refs and the prefix the world is built with.
+type worldBuilder struct {
+	sc *scenario
+}
+
+func newWorldBuilder() *worldBuilder {
+	return &worldBuilder{sc: &scenario{dir: "."}}
+}
+
+type orgBuilder struct {
+	w   *worldBuilder
+	org *scenarioOrg
+}
+
+func (b *worldBuilder) org(ref string) *orgBuilder {
+	o := &scenarioOrg{Ref: ref, Name: ref}
+	b.sc.Orgs = append(b.sc.Orgs, o)
+
+	return &orgBuilder{b, o}
+}
+
+func (o *orgBuilder) named(name string) *orgBuilder {
+	o.org.Name = name
+	return o
+}
+
+type userBuilder struct {
+	user *scenarioUser
+}
+
+// user adds a user with roles, whose email derives from the ref and logs in
+// with "password".
+func (o *orgBuilder) user(ref string, roles ...string) *userBuilder {
+	u := &scenarioUser{
+		Ref:        ref,
+		FirstName:  "David",
+		LastName:   "Borry",
+		Roles:      roles,
+		Attributes: map[string]string{},
+	}
+	o.org.Users = append(o.org.Users, u)
+
+	return &userBuilder{u}
+}
+
+func (o *orgBuilder) admin(ref string) *userBuilder {
+	return o.user(ref, "ROLE_ADMIN")
+}
+
+func (o *orgBuilder) learner(ref string) *userBuilder {
+	return o.user(ref, "ROLE_LEARNER")
+}
+
+// attribute sets the user's value of the attribute ref.
+func (u *userBuilder) attribute(ref, value string) *userBuilder {
+	u.user.Attributes[ref] = value
+	return u
+}
+
+// attribute adds a SINGLE_SELECT attribute with options.
+func (o *orgBuilder) attribute(ref string, options ...string) *orgBuilder {
+	o.org.Attributes = append(o.org.Attributes, &scenarioAttribute{Ref: ref, Name: ref, Type: "SINGLE_SELECT", Options: options})
+	return o
+}
+
+type groupBuilder struct {
+	group *scenarioGroup
+}
+
+func (o *orgBuilder) group(ref string) *groupBuilder {
+	g := &scenarioGroup{Ref: ref, Name: ref}
+	o.org.Groups = append(o.org.Groups, g)
+
+	return &groupBuilder{g}
+}
+
+// filter keeps the users whose attribute ref compares to value with operator.
+func (g *groupBuilder) filter(ref, operator, value string) *groupBuilder {
+	g.group.Filters = append(g.group.Filters, &scenarioFilter{Attribute: ref, Operator: operator, Value: value})
+	return g
+}
+
+type courseBuilder struct {
+	course *scenarioCourse
+}
+
+func (o *orgBuilder) course(ref string) *courseBuilder {
+	c := &scenarioCourse{Ref: ref, Title: ref, Publish: true}
+	o.org.Courses = append(o.org.Courses, c)
+
+	return &courseBuilder{c}
+}
+
+func (c *courseBuilder) titled(title string) *courseBuilder {
+	c.course.Title = title
+	return c
+}
+
+// draft leaves the course unpublished.
+func (c *courseBuilder) draft() *courseBuilder {
+	c.course.Publish = false
+	return c
+}
+
+// item adds a learning item with the cards of cardsPath, relative to the
+// suite's directory.
+func (c *courseBuilder) item(ref, itemType, cardsPath string) *courseBuilder {
+	c.course.Items = append(c.course.Items, &scenarioItem{Ref: ref, Type: itemType, Name: ref, Points: 1, Cards: cardsPath})
+	return c
+}
+
+// lesson adds a lesson with the cards of testdata/cards.json.
+func (c *courseBuilder) lesson(ref string) *courseBuilder {
+	return c.item(ref, "lesson", "./testdata/cards.json")
+}
+
+// quiz adds a quiz with the cards of testdata/quiz.json.
+func (c *courseBuilder) quiz(ref string) *courseBuilder {
+	return c.item(ref, "quiz", "./testdata/quiz.json")
+}
+
+type planBuilder struct {
+	plan *scenarioPlan
+}
+
+func (o *orgBuilder) plan(ref string) *planBuilder {
+	p := &scenarioPlan{Ref: ref, Name: ref, Activate: true}
+	o.org.Plans = append(o.org.Plans, p)
+
+	return &planBuilder{p}
+}
+
+func (p *planBuilder) courses(refs ...string) *planBuilder {
+	p.plan.Courses = append(p.plan.Courses, refs...)
+	return p
+}
+
+func (p *planBuilder) groups(refs ...string) *planBuilder {
+	p.plan.Groups = append(p.plan.Groups, refs...)
+	return p
+}
+
+// inactive leaves the plan inactive, so it invites no one.
+func (p *planBuilder) inactive() *planBuilder {
+	p.plan.Activate = false
+	return p
+}
+
+// scenario returns the built scenario, checked as a scenario file would be,
+// its slugs and emails starting with prefix.
+func (b *worldBuilder) scenario(prefix string) (*scenario, error) {
+	for _, o := range b.sc.Orgs {
+		o.Slug = fmt.Sprintf("%s-%s", prefix, o.Ref)
+		for _, u := range o.Users {
+			u.Email = fmt.Sprintf("dborry+e2e_%s_%s@learntowin.com", prefix, u.Ref)
+		}
+	}
+
+	if err := b.sc.validate(); err != nil {
+		return nil, fmt.Errorf("invalid world: %w", err)
+	}
+
+	return b.sc, nil
+}
+
+// world is what a worldBuilder provisioned, looked up by ref.
+type world struct {
+	sc *scenario
+	p  *provisioned
+}
+
+func (w *world) org(ref string) *organization {
+	return w.p.orgs[ref]
+}
+
+func (w *world) user(ref string) *user {
+	return w.p.users[ref]
+}
+
+// as returns the credentials of the user ref, or of the admin of the org ref.
+func (w *world) as(ref string) userCredentials {
+	return w.p.credentials[ref]
+}
+
+// adminOf returns the credentials of the admin of the org that ref belongs to.
+func (w *world) adminOf(ref string) userCredentials {
+	return w.p.credentials[w.sc.owner(ref)]
+}
+
+func (w *world) group(ref string) *learningGroup {
+	return w.p.groups[ref]
+}
+
+func (w *world) course(ref string) *course {
+	return w.p.courses[ref]
+}
+
+func (w *world) item(ref string) *learningItem {
+	return w.p.items[ref]
+}
+
+func (w *world) cards(ref string) []*card {
+	return w.p.cards[ref]
+}
+
+func (w *world) plan(ref string) *learningPlan {
+	return w.p.plans[ref]
+}
+
+// id returns the id of the entity ref points at, attributes included.
+func (w *world) id(ref string) string {
+	return w.p.id(ref)
+}
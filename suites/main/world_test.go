@@ -0,0 +1,56 @@
+package main_suite_test
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+// worldPrefix numbers the worlds of the running test from its name and their
+// rank in it, so that their slugs and emails neither collide with those of
+// other tests nor depend on which tests ran before, as replays need.
+func (s *harness) worldPrefix() string {
+	s.worlds += 1
+	h := sha256.Sum256([]byte(s.apiClient.test))
+
+	return fmt.Sprintf("w%x-%d", h[:3], s.worlds)
+}
+
+// buildWorld provisions the world of b for the running test, AfterTest
+// deleting its orgs.
+func (s *harness) buildWorld(b *worldBuilder) *world {
+	sc, err := b.scenario(s.worldPrefix())
+	s.Require().Nil(err)
+
+	return &world{sc, s.provision(sc)}
+}
+
+// setupWorld provisions the world of b for the whole suite, its orgs deleted
+// by TearDownSuite.
+func (s *harness) setupWorld(b *worldBuilder) *world {
+	sc, err := b.scenario(s.worldPrefix())
+	s.Require().Nil(err)
+
+	p, err := sc.provision(s.apiClient, s.superAdmin)
+	for _, o := range p.orgs {
+		s.orgs = append(s.orgs, o)
+	}
+	s.Require().Nil(err)
+
+	return &world{sc, p}
+}
+
+// geographyWorld is a school whose blue learner is invited to a geography
+// course, as courseSuite provisions it, and whose red learner is not.
+func geographyWorld() *worldBuilder {
+	b := newWorldBuilder()
+	school := b.org("school")
+	school.admin("admin")
+	school.learner("blue").attribute("color", "Blue")
+	school.learner("red").attribute("color", "Red")
+	school.attribute("color", "Red", "Green", "Blue")
+	school.group("blues").filter("color", "EQ", "Blue")
+	school.course("geography").lesson("intro").quiz("test")
+	school.plan("semester").courses("geography").groups("blues")
+
+	return b
+}